@@ -0,0 +1,220 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	AlertStatusFiring   = "firing"
+	AlertStatusResolved = "resolved"
+)
+
+// severities ordered from the least to the most severe
+var severityOrder = map[string]int{
+	"info":     1,
+	"warning":  2,
+	"error":    3,
+	"critical": 4,
+}
+
+var severityColors = map[string]int{
+	"info":     0x3498DB,
+	"warning":  0xF1C40F,
+	"error":    0xE67E22,
+	"critical": 0xE01E5A,
+}
+
+const resolvedColor = 0x2ECC71
+
+type Alert struct {
+	Status      string            `json:"status"`
+	Name        string            `json:"name"`
+	Severity    string            `json:"severity"`
+	Summary     string            `json:"summary"`
+	Description string            `json:"description"`
+	StartsAt    time.Time         `json:"starts_at"`
+	EndsAt      time.Time         `json:"ends_at"`
+	Labels      map[string]string `json:"labels"`
+}
+
+// AlertNotification groups the alerts of a single Alertmanager notification,
+// it is sent as a single card
+type AlertNotification struct {
+	Id          string            `json:"id"`
+	Status      string            `json:"status"`
+	GroupLabels map[string]string `json:"group_labels"`
+	ExternalURL string            `json:"external_url"`
+	Alerts      []Alert           `json:"alerts"`
+	OnCall      string            `json:"on_call"`
+	Roast       string            `json:"roast"`
+}
+
+// Severity is the highest severity among the alerts
+func (n AlertNotification) Severity() string {
+	severity := ""
+	for _, a := range n.Alerts {
+		if severityOrder[a.Severity] > severityOrder[severity] {
+			severity = a.Severity
+		}
+	}
+
+	return severity
+}
+
+// Color is the card color, green once resolved or else by severity
+func (n AlertNotification) Color() int {
+	if n.Status == AlertStatusResolved {
+		return resolvedColor
+	}
+
+	color, ok := severityColors[n.Severity()]
+	if !ok {
+		return severityColors["warning"]
+	}
+
+	return color
+}
+
+// HexColor is Color in the #rrggbb notation
+func (n AlertNotification) HexColor() string {
+	return fmt.Sprintf("#%06x", n.Color())
+}
+
+func (n AlertNotification) Title() string {
+	name := n.GroupLabels["alertname"]
+	if name == "" && len(n.Alerts) > 0 {
+		name = n.Alerts[0].Name
+	}
+
+	if n.Status == AlertStatusResolved {
+		return fmt.Sprintf("[RESOLVIDO:%d] %s", len(n.Alerts), name)
+	}
+
+	return fmt.Sprintf("[DISPARANDO:%d] %s", len(n.Alerts), name)
+}
+
+type alertmanagerAlert struct {
+	Status       string            `json:"status"`
+	Labels       map[string]string `json:"labels"`
+	Annotations  map[string]string `json:"annotations"`
+	StartsAt     time.Time         `json:"startsAt"`
+	EndsAt       time.Time         `json:"endsAt"`
+	GeneratorURL string            `json:"generatorURL"`
+	Fingerprint  string            `json:"fingerprint"`
+}
+
+// alertmanagerNotification is the Alertmanager webhook payload, version 4
+type alertmanagerNotification struct {
+	Version           string              `json:"version"`
+	GroupKey          string              `json:"groupKey"`
+	Status            string              `json:"status"`
+	Receiver          string              `json:"receiver"`
+	GroupLabels       map[string]string   `json:"groupLabels"`
+	CommonLabels      map[string]string   `json:"commonLabels"`
+	CommonAnnotations map[string]string   `json:"commonAnnotations"`
+	ExternalURL       string              `json:"externalURL"`
+	Alerts            []alertmanagerAlert `json:"alerts"`
+}
+
+func (p alertmanagerNotification) toAlertNotification() AlertNotification {
+	notification := AlertNotification{
+		Id:          uuid.NewString(),
+		Status:      p.Status,
+		GroupLabels: p.GroupLabels,
+		ExternalURL: p.ExternalURL,
+	}
+
+	for _, a := range p.Alerts {
+		notification.Alerts = append(notification.Alerts, Alert{
+			Status:      a.Status,
+			Name:        a.Labels["alertname"],
+			Severity:    a.Labels["severity"],
+			Summary:     a.Annotations["summary"],
+			Description: a.Annotations["description"],
+			StartsAt:    a.StartsAt,
+			EndsAt:      a.EndsAt,
+			Labels:      a.Labels,
+		})
+	}
+
+	return notification
+}
+
+// onCall finds who is on call from the notification labels, falling back to
+// the configured default
+func (p alertmanagerNotification) onCall(label string, fallback string) string {
+	if v := p.CommonLabels[label]; v != "" {
+		return v
+	}
+
+	if v := p.CommonAnnotations[label]; v != "" {
+		return v
+	}
+
+	return fallback
+}
+
+func (s *Server) SendAlertmanagerWebhook(c echo.Context) error {
+	ctx, payloads := WithDryRunPayloads(c.Request().Context())
+
+	if !s.sendMessages {
+		return c.JSON(403, map[string]string{"error": "sending messages is disabled"})
+	}
+
+	var payload alertmanagerNotification
+	if err := c.Bind(&payload); err != nil || len(payload.Alerts) == 0 {
+		return c.JSON(400, map[string]string{"error": "invalid request"})
+	}
+
+	notification := payload.toAlertNotification()
+
+	if s.alertmanager.Roast && notification.Status == AlertStatusFiring {
+		notification.OnCall = payload.onCall(s.alertmanager.OnCallLabel, s.alertmanager.DefaultOnCall)
+		notification.Roast = s.roast(ctx, notification.OnCall)
+	}
+
+	err := s.messageSender.SendAlert(ctx, notification)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	return sentResponse(c, payloads, "alert sent")
+}
+
+// roast picks the roast of the on call, nobody is roasted on vacation and
+// there is no roast without an on call
+func (s *Server) roast(ctx context.Context, onCall string) string {
+	if onCall == "" {
+		return ""
+	}
+
+	if IsTeamMemberAway(ctx, s.rosterStorer, onCall, time.Now()) {
+		slog.InfoContext(ctx, "not roasting the on call, they are away", slog.String("on_call", onCall))
+		return ""
+	}
+
+	roast, err := GetRandomMessage(ctx, s.messageStorer, s.alertmanager.RoastTag)
+	if errors.Is(err, ErrMessageNotFound) {
+		roast, err = GetRandomMessage(ctx, s.messageStorer, "")
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "failed to pick a roast for the alert", slog.Any("error", err))
+		return ""
+	}
+
+	// the on call is the one being roasted
+	rendered, err := RenderPlaceholders(roast.Message, WithPlaceholderDefaults(s.placeholders, map[string]string{"name": onCall}), time.Now())
+	if err != nil {
+		slog.WarnContext(ctx, "failed to render the roast placeholders", slog.Any("error", err))
+		return ""
+	}
+
+	return rendered
+}
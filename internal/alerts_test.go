@@ -0,0 +1,188 @@
+package internal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+const alertmanagerPayload = `{
+  "version": "4",
+  "groupKey": "{}:{alertname=\"HighLatency\"}",
+  "status": "firing",
+  "receiver": "wilson",
+  "groupLabels": {"alertname": "HighLatency"},
+  "commonLabels": {"alertname": "HighLatency", "oncall": "Flemis"},
+  "commonAnnotations": {},
+  "externalURL": "http://alertmanager:9093",
+  "alerts": [
+    {
+      "status": "firing",
+      "labels": {"alertname": "HighLatency", "severity": "warning", "instance": "api-1"},
+      "annotations": {"summary": "p99 acima de 2s"},
+      "startsAt": "2026-10-18T10:00:00Z",
+      "endsAt": "0001-01-01T00:00:00Z"
+    },
+    {
+      "status": "firing",
+      "labels": {"alertname": "HighLatency", "severity": "critical", "instance": "api-2"},
+      "annotations": {"summary": "p99 acima de 5s"},
+      "startsAt": "2026-10-18T10:01:00Z",
+      "endsAt": "0001-01-01T00:00:00Z"
+    }
+  ]
+}`
+
+func newAlertmanagerContext(e *echo.Echo, body string) (echo.Context, *httptest.ResponseRecorder) {
+	req := httptest.NewRequest(http.MethodPost, "/webhook/alertmanager", strings.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	return e.NewContext(req, rec), rec
+}
+
+func TestAlertNotificationColor(t *testing.T) {
+	notification := AlertNotification{
+		Status: AlertStatusFiring,
+		Alerts: []Alert{{Severity: "warning"}, {Severity: "critical"}},
+	}
+
+	assert.Equal(t, "critical", notification.Severity())
+	assert.Equal(t, severityColors["critical"], notification.Color())
+
+	notification.Status = AlertStatusResolved
+	assert.Equal(t, resolvedColor, notification.Color())
+}
+
+func TestSendAlertmanagerWebhookGroupsAlerts(t *testing.T) {
+	e := echo.New()
+	mockSender := NewMockMessageSender(t)
+	mockSender.On("SendAlert", mock.Anything, mock.MatchedBy(func(n AlertNotification) bool {
+		return len(n.Alerts) == 2 && n.Title() == "[DISPARANDO:2] HighLatency" && n.Roast == ""
+	})).Return(nil).Once()
+
+	server := &Server{messageSender: mockSender, sendMessages: true, echoServer: e}
+
+	c, rec := newAlertmanagerContext(e, alertmanagerPayload)
+	err := server.SendAlertmanagerWebhook(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestSendAlertmanagerWebhookRoastsOnCall(t *testing.T) {
+	e := echo.New()
+	mockStore := NewMockMessageStorer(t)
+	mockStore.On("GetAllMessages", mock.Anything).Return([]Message{
+		{Id: "1", Message: "Quem mandou subir na sexta?", Tags: []string{"roast"}},
+	}, nil)
+
+	mockSender := NewMockMessageSender(t)
+	mockSender.On("SendAlert", mock.Anything, mock.MatchedBy(func(n AlertNotification) bool {
+		return n.OnCall == "Flemis" && n.Roast == "Quem mandou subir na sexta?"
+	})).Return(nil).Once()
+
+	server := &Server{
+		messageStorer: mockStore,
+		messageSender: mockSender,
+		sendMessages:  true,
+		rosterStorer:  NewInMemoryRosterStorer(),
+		alertmanager:  AlertmanagerConfig{Roast: true, RoastTag: "roast", OnCallLabel: "oncall"},
+		echoServer:    e,
+	}
+
+	c, rec := newAlertmanagerContext(e, alertmanagerPayload)
+	err := server.SendAlertmanagerWebhook(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestSendAlertmanagerWebhookSparesOnCallAway(t *testing.T) {
+	e := echo.New()
+	mockStore := NewMockMessageStorer(t)
+
+	mockSender := NewMockMessageSender(t)
+	mockSender.On("SendAlert", mock.Anything, mock.MatchedBy(func(n AlertNotification) bool {
+		return n.OnCall == "Flemis" && n.Roast == ""
+	})).Return(nil).Once()
+
+	server := &Server{
+		messageStorer: mockStore,
+		messageSender: mockSender,
+		sendMessages:  true,
+		rosterStorer:  NewInMemoryRosterStorer(TeamMember{Id: "flemis", Name: "Flemis", Away: true}),
+		alertmanager:  AlertmanagerConfig{Roast: true, RoastTag: "roast", OnCallLabel: "oncall"},
+		echoServer:    e,
+	}
+
+	c, rec := newAlertmanagerContext(e, alertmanagerPayload)
+	err := server.SendAlertmanagerWebhook(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestSendAlertmanagerWebhookInvalidPayload(t *testing.T) {
+	e := echo.New()
+	server := &Server{messageSender: NewMockMessageSender(t), sendMessages: true, echoServer: e}
+
+	c, rec := newAlertmanagerContext(e, `{"status": "firing", "alerts": []}`)
+	err := server.SendAlertmanagerWebhook(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestRenderAlertIsValidJSON(t *testing.T) {
+	renderer := newGoogleChatCardRenderer(mustGoogleChatTemplates(t))
+
+	var payload alertmanagerNotification
+	require.NoError(t, json.Unmarshal([]byte(alertmanagerPayload), &payload))
+
+	notification := payload.toAlertNotification()
+	notification.OnCall = "Flemis"
+	notification.Roast = "Quem mandou subir na sexta?"
+
+	body, err := renderer.renderAlert(t.Context(), notification, NewFitter(LimitModeTruncate))
+	require.NoError(t, err)
+	assert.True(t, json.Valid(body), string(body))
+	assert.Contains(t, string(body), notification.HexColor())
+
+	// the card headers don't render markup
+	var card struct {
+		CardsV2 []struct {
+			Card struct {
+				Header map[string]string `json:"header"`
+			} `json:"card"`
+		} `json:"cardsV2"`
+	}
+	require.NoError(t, json.Unmarshal(body, &card))
+	for _, value := range card.CardsV2[0].Card.Header {
+		assert.NotContains(t, value, "<font")
+	}
+}
+
+func TestRenderAlertCapsItems(t *testing.T) {
+	renderer := newGoogleChatCardRenderer(mustGoogleChatTemplates(t))
+
+	notification := AlertNotification{Status: AlertStatusFiring}
+	for range 20000 {
+		notification.Alerts = append(notification.Alerts, Alert{
+			Name:    strings.Repeat("HighLatency", 100),
+			Summary: strings.Repeat("latência alta ", 100),
+		})
+	}
+
+	body, err := renderer.renderAlert(t.Context(), notification, NewFitter(LimitModeTruncate))
+	require.NoError(t, err)
+	assert.True(t, json.Valid(body))
+	assert.Less(t, len(body), 32000)
+}
@@ -2,53 +2,130 @@ package internal
 
 import (
 	"context"
+	"errors"
 	"log/slog"
+	"maps"
 	"math/rand"
 	"time"
 
 	"github.com/labstack/echo/v4"
 	"github.com/labstack/echo/v4/middleware"
 	slogecho "github.com/samber/slog-echo"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/labstack/echo/otelecho"
 )
 
+const serviceName = "wilson-bot"
+
+// RouteRegisterer is implemented by handlers living outside this package
+// that need to expose their own endpoints
+type RouteRegisterer interface {
+	RegisterRoutes(g *echo.Group)
+}
+
 type Server struct {
 	messageStorer MessageStorer
 	messageSender MessageSender
+	apiKeyStorer  APIKeyStorer
+	imageStorer   ImageStorer
+	historyStorer HistoryStorer
+	rosterStorer  RosterStorer
+	rotation      *Rotation
+	schedule      Schedule
+	webhooks      *WebhookSignatureVerifier
+	alertmanager  AlertmanagerConfig
+	placeholders  map[string]string
 	sendMessages  bool
+	authEnabled   bool
 	echoServer    *echo.Echo
+	api           *echo.Group
+
+	readinessChecks []readinessCheck
+	renderers       map[string]Renderer
+	editors         map[string]MessageEditor
+	pipeline        func(next MessageSender) MessageSender
 }
 
 func NewServer(
 	cfg HTTPConfig,
 	messageStorer MessageStorer,
 	messageSender MessageSender,
+	apiKeyStorer APIKeyStorer,
+	imageStorer ImageStorer,
+	historyStorer HistoryStorer,
+	rosterStorer RosterStorer,
 ) *Server {
 	e := echo.New()
 
+	e.Use(otelecho.Middleware(serviceName))
 	e.Use(slogecho.New(slog.Default()))
 	e.Use(middleware.Recover())
+	e.Use(metricsMiddleware())
+
+	if cfg.Metrics.Enabled {
+		e.GET(cfg.Metrics.Path, metricsHandler())
+	}
 
 	server := &Server{
 		messageStorer: messageStorer,
 		messageSender: messageSender,
+		apiKeyStorer:  apiKeyStorer,
+		imageStorer:   imageStorer,
+		historyStorer: historyStorer,
+		rosterStorer:  rosterStorer,
+		webhooks:      NewWebhookSignatureVerifier(cfg.WebhookSignature),
+		alertmanager:  cfg.Alertmanager,
 		echoServer:    e,
 		sendMessages:  cfg.EnableSend,
+		authEnabled:   cfg.Auth.Enabled,
 	}
 
 	api := e.Group(cfg.Prefix)
+	server.api = api
+
 	api.GET("/healthz", func(c echo.Context) error {
 		return c.JSON(200, map[string]string{"status": "ok"})
 	})
-
-	messagesRouter := api.Group("/messages")
-
-	messagesRouter.GET("/", server.GetAllMessages)
-	messagesRouter.GET("/:id", server.GetMessageById)
-	messagesRouter.POST("/", server.SendMessage)
-	messagesRouter.POST("/:id", server.SendMessageById)
-
-	webhookRouter := api.Group("/webhook")
-	webhookRouter.POST("/broken", server.SendBrokenMessageWebhook)
+	api.GET("/livez", server.Livez)
+	api.GET("/readyz", server.Readyz)
+
+	messagesRouter := api.Group("/messages", TriggerMiddleware(TriggerAPI))
+
+	messagesRouter.GET("/", server.GetAllMessages, server.requireScope(ScopeMessagesRead))
+	messagesRouter.GET("/:id", server.GetMessageById, server.requireScope(ScopeMessagesRead))
+	messagesRouter.GET("/:id/preview", server.PreviewMessage, server.requireScope(ScopeMessagesRead))
+	messagesRouter.POST("/:id/preview", server.PreviewMessage, server.requireScope(ScopeMessagesRead))
+	messagesRouter.POST("/", server.SendMessage, server.requireScope(ScopeSend))
+	messagesRouter.POST("/:id", server.SendMessageById, server.requireScope(ScopeSend))
+
+	imagesRouter := api.Group("/images")
+	imagesRouter.GET("/", server.GetAllImages, server.requireScope(ScopeMessagesRead))
+	imagesRouter.POST("/", server.CreateImage, server.requireScope(ScopeMessagesWrite))
+	imagesRouter.DELETE("/:id", server.DeleteImage, server.requireScope(ScopeMessagesWrite))
+
+	rosterRouter := api.Group("/roster")
+	rosterRouter.GET("/", server.GetAllTeamMembers, server.requireScope(ScopeMessagesRead))
+	rosterRouter.POST("/", server.CreateTeamMember, server.requireScope(ScopeMessagesWrite))
+	rosterRouter.GET("/next", server.GetNextTarget, server.requireScope(ScopeMessagesRead))
+	rosterRouter.PATCH("/:id", server.UpdateTeamMember, server.requireScope(ScopeMessagesWrite))
+	rosterRouter.DELETE("/:id", server.DeleteTeamMember, server.requireScope(ScopeMessagesWrite))
+	rosterRouter.POST("/:id/out-of-office", server.AddOutOfOffice, server.requireScope(ScopeMessagesWrite))
+	rosterRouter.DELETE("/:id/out-of-office/:ooo_id", server.DeleteOutOfOffice, server.requireScope(ScopeMessagesWrite))
+	rosterRouter.POST("/out-of-office/import", server.ImportOutOfOfficeCalendar, server.requireScope(ScopeMessagesWrite))
+
+	historyRouter := api.Group("/history")
+	historyRouter.GET("/", server.GetSendRecords, server.requireScope(ScopeMessagesRead))
+	historyRouter.PATCH("/:id", server.EditSendRecord, server.requireScope(ScopeSend))
+	historyRouter.DELETE("/:id", server.DeleteSendRecord, server.requireScope(ScopeSend))
+
+	webhookRouter := api.Group("/webhook", TriggerMiddleware(TriggerWebhook))
+	webhookRouter.POST("/broken", server.SendBrokenMessageWebhook, server.webhooks.Middleware(), server.requireScope(ScopeBrokenWrite))
+	webhookRouter.POST("/broken/preview", server.PreviewBrokenMessage, server.requireScope(ScopeBrokenWrite))
+	webhookRouter.POST("/alertmanager", server.SendAlertmanagerWebhook, server.requireScope(ScopeSend))
+
+	adminRouter := api.Group("/admin", server.requireScope(ScopeAdmin))
+	adminRouter.GET("/keys", server.GetAllAPIKeys)
+	adminRouter.POST("/keys", server.CreateAPIKey)
+	adminRouter.DELETE("/keys/:id", server.RevokeAPIKey)
 
 	return server
 }
@@ -77,6 +154,35 @@ func (s *Server) GetMessageById(c echo.Context) error {
 	return c.JSON(500, map[string]string{"error": err.Error()})
 }
 
+// sendMessageRequest is the optional body of the message sends, vars fill
+// the message placeholders and target is who the message is meant for, by
+// roster id, name or alias
+type sendMessageRequest struct {
+	Vars   map[string]string `json:"vars"`
+	Target string            `json:"target"`
+}
+
+// sendContext carries the vars and the target of the send, the target also
+// fills the name placeholder when the vars don't, with the roster name when
+// the target is in the roster
+func (s *Server) sendContext(ctx context.Context, req sendMessageRequest) context.Context {
+	vars := req.Vars
+	if _, ok := vars["name"]; req.Target != "" && !ok {
+		name := req.Target
+		if member, err := FindTeamMember(ctx, s.rosterStorer, req.Target); err == nil {
+			name = member.Name
+		}
+
+		vars = maps.Clone(vars)
+		if vars == nil {
+			vars = make(map[string]string)
+		}
+		vars["name"] = name
+	}
+
+	return WithMessageTarget(WithMessageVars(ctx, vars), req.Target)
+}
+
 func (s *Server) SendMessageById(c echo.Context) error {
 	id := c.Param("id")
 
@@ -84,17 +190,27 @@ func (s *Server) SendMessageById(c echo.Context) error {
 		return c.JSON(403, map[string]string{"error": "sending messages is disabled"})
 	}
 
-	message, err := s.messageStorer.GetMessageByID(c.Request().Context(), id)
+	var req sendMessageRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(400, map[string]string{"error": "invalid request"})
+	}
+
+	ctx, payloads := WithDryRunPayloads(s.sendContext(c.Request().Context(), req))
+
+	message, err := s.messageStorer.GetMessageByID(ctx, id)
 	if err != nil {
 		return c.JSON(500, map[string]string{"error": err.Error()})
 	}
 
-	err = s.messageSender.SendMessage(c.Request().Context(), *message)
+	err = s.messageSender.SendMessage(ctx, *message)
+	if errors.Is(err, ErrMissingVariable) {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
 	if err != nil {
 		return c.JSON(500, map[string]string{"error": err.Error()})
 	}
 
-	return c.JSON(200, map[string]string{"message": "message sent"})
+	return sentResponse(c, payloads, "message sent")
 }
 
 func (s *Server) SendMessage(c echo.Context) error {
@@ -102,7 +218,14 @@ func (s *Server) SendMessage(c echo.Context) error {
 		return c.JSON(403, map[string]string{"error": "sending messages is disabled"})
 	}
 
-	messages, err := s.messageStorer.GetAllMessages(c.Request().Context())
+	var req sendMessageRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(400, map[string]string{"error": "invalid request"})
+	}
+
+	ctx, payloads := WithDryRunPayloads(s.sendContext(c.Request().Context(), req))
+
+	messages, err := s.messageStorer.GetAllMessages(ctx)
 	if err != nil {
 		return c.JSON(500, map[string]string{"error": err.Error()})
 	}
@@ -118,12 +241,15 @@ func (s *Server) SendMessage(c echo.Context) error {
 	randomIndex := rand.Intn(len(messages))
 	randomMessage := messages[randomIndex]
 
-	err = s.messageSender.SendMessage(c.Request().Context(), randomMessage)
+	err = s.messageSender.SendMessage(ctx, randomMessage)
+	if errors.Is(err, ErrMissingVariable) {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
 	if err != nil {
 		return c.JSON(500, map[string]string{"error": err.Error()})
 	}
 
-	return c.JSON(200, map[string]string{"message": "message sent"})
+	return sentResponse(c, payloads, "message sent")
 }
 
 func (s *Server) SendBrokenMessageWebhook(c echo.Context) error {
@@ -136,12 +262,19 @@ func (s *Server) SendBrokenMessageWebhook(c echo.Context) error {
 		return c.JSON(400, map[string]string{"error": "invalid request"})
 	}
 
-	err := s.messageSender.SendBrokenMessage(c.Request().Context(), brokenMessage)
+	ctx, payloads := WithDryRunPayloads(c.Request().Context())
+
+	err := s.messageSender.SendBrokenMessage(ctx, brokenMessage)
 	if err != nil {
 		return c.JSON(500, map[string]string{"error": err.Error()})
 	}
 
-	return c.JSON(200, map[string]string{"message": "broken message sent"})
+	return sentResponse(c, payloads, "broken message sent")
+}
+
+// Register mounts the routes of the registerer under the API prefix
+func (s *Server) Register(registerer RouteRegisterer) {
+	registerer.RegisterRoutes(s.api)
 }
 
 func (s *Server) Start(addr string) error {
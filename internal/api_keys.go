@@ -0,0 +1,151 @@
+package internal
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type APIKeyScope string
+
+const (
+	ScopeMessagesRead  APIKeyScope = "messages:read"
+	ScopeMessagesWrite APIKeyScope = "messages:write"
+	ScopeSend          APIKeyScope = "send"
+	ScopeBrokenWrite   APIKeyScope = "broken:write"
+	ScopeAdmin         APIKeyScope = "admin"
+
+	apiKeyTokenPrefix = "wb_"
+)
+
+var (
+	ErrAPIKeyNotFound = errors.New("api key not found")
+	ErrInvalidScope   = errors.New("invalid api key scope")
+
+	AllScopes = []APIKeyScope{ScopeMessagesRead, ScopeMessagesWrite, ScopeSend, ScopeBrokenWrite, ScopeAdmin}
+)
+
+// APIKey is the stored representation of a key, the token itself is only
+// known when the key is issued, afterwards only its hash is kept
+type APIKey struct {
+	Id        string        `json:"id"`
+	Name      string        `json:"name"`
+	Prefix    string        `json:"prefix"`
+	Hash      string        `json:"-"`
+	Scopes    []APIKeyScope `json:"scopes"`
+	CreatedAt time.Time     `json:"created_at"`
+	RevokedAt *time.Time    `json:"revoked_at,omitempty"`
+}
+
+// HasScope reports if the key grants the scope, admin keys grant everything
+func (k APIKey) HasScope(scope APIKeyScope) bool {
+	return slices.Contains(k.Scopes, ScopeAdmin) || slices.Contains(k.Scopes, scope)
+}
+
+func (k APIKey) Revoked() bool {
+	return k.RevokedAt != nil
+}
+
+// NewAPIKey generates a random token and the key that represents it
+func NewAPIKey(name string, scopes []APIKeyScope) (string, *APIKey, error) {
+	for _, scope := range scopes {
+		if !slices.Contains(AllScopes, scope) {
+			return "", nil, ErrInvalidScope
+		}
+	}
+
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", nil, err
+	}
+
+	token := apiKeyTokenPrefix + base64.RawURLEncoding.EncodeToString(secret)
+
+	return token, &APIKey{
+		Id:        uuid.NewString(),
+		Name:      name,
+		Prefix:    token[:len(apiKeyTokenPrefix)+6],
+		Hash:      HashAPIKey(token),
+		Scopes:    scopes,
+		CreatedAt: time.Now(),
+	}, nil
+}
+
+// HashAPIKey hashes a token for storage, tokens are random enough for a plain
+// SHA-256 to be safe
+func HashAPIKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+type APIKeyStorer interface {
+	CreateAPIKey(ctx context.Context, key APIKey) error
+	GetAllAPIKeys(ctx context.Context) ([]APIKey, error)
+	GetAPIKeyByHash(ctx context.Context, hash string) (*APIKey, error)
+	RevokeAPIKey(ctx context.Context, id string) error
+}
+
+type InMemoryAPIKeyStorer struct {
+	mu   sync.RWMutex
+	keys []APIKey
+}
+
+var (
+	_ APIKeyStorer = (*InMemoryAPIKeyStorer)(nil)
+)
+
+func NewInMemoryAPIKeyStorer() *InMemoryAPIKeyStorer {
+	return &InMemoryAPIKeyStorer{}
+}
+
+func (s *InMemoryAPIKeyStorer) CreateAPIKey(ctx context.Context, key APIKey) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.keys = append(s.keys, key)
+
+	return nil
+}
+
+func (s *InMemoryAPIKeyStorer) GetAllAPIKeys(ctx context.Context) ([]APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.keys), nil
+}
+
+func (s *InMemoryAPIKeyStorer) GetAPIKeyByHash(ctx context.Context, hash string) (*APIKey, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, k := range s.keys {
+		if k.Hash == hash {
+			return &k, nil
+		}
+	}
+
+	return nil, ErrAPIKeyNotFound
+}
+
+func (s *InMemoryAPIKeyStorer) RevokeAPIKey(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for i := range s.keys {
+		if s.keys[i].Id == id {
+			now := time.Now()
+			s.keys[i].RevokedAt = &now
+			return nil
+		}
+	}
+
+	return ErrAPIKeyNotFound
+}
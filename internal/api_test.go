@@ -78,10 +78,10 @@ func TestGetMessageByIdNotFound(t *testing.T) {
 
 func TestNewServer(t *testing.T) {
 	mockStore := NewMockMessageStorer(t)
-	mockGoogleProvider := NewMockGoogleChatProvider(t)
+	mockSender := NewMockMessageSender(t)
 	cfg := HTTPConfig{Prefix: "/api"}
 
-	server := NewServer(cfg, mockStore, mockGoogleProvider)
+	server := NewServer(cfg, mockStore, mockSender, NewInMemoryAPIKeyStorer(), NewInMemoryImageStorer(), NewInMemoryHistoryStorer(), NewInMemoryRosterStorer())
 
 	assert.NotNil(t, server)
 	assert.NotNil(t, server.echoServer)
@@ -0,0 +1,116 @@
+package internal
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	apiKeyHeader     = "X-API-Key"
+	apiKeyContextKey = "api_key"
+)
+
+type createAPIKeyRequest struct {
+	Name   string        `json:"name"`
+	Scopes []APIKeyScope `json:"scopes"`
+}
+
+type createAPIKeyResponse struct {
+	Token  string `json:"token"`
+	APIKey APIKey `json:"api_key"`
+}
+
+// requireScope only lets through requests carrying a valid, non revoked API
+// key with the scope, either as a bearer token or in the X-API-Key header.
+// Webhooks already authenticated by their signature are let through
+func (s *Server) requireScope(scope APIKeyScope) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			if !s.authEnabled {
+				return next(c)
+			}
+
+			if _, ok := c.Get(webhookSourceContextKey).(string); ok {
+				return next(c)
+			}
+
+			token := c.Request().Header.Get(apiKeyHeader)
+			if bearer, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer "); ok {
+				token = bearer
+			}
+
+			if token == "" {
+				return c.JSON(401, map[string]string{"error": "missing api key"})
+			}
+
+			key, err := s.apiKeyStorer.GetAPIKeyByHash(c.Request().Context(), HashAPIKey(token))
+			if errors.Is(err, ErrAPIKeyNotFound) || (err == nil && key.Revoked()) {
+				return c.JSON(401, map[string]string{"error": "invalid api key"})
+			}
+			if err != nil {
+				return c.JSON(500, map[string]string{"error": err.Error()})
+			}
+
+			if !key.HasScope(scope) {
+				return c.JSON(403, map[string]string{"error": "missing scope " + string(scope)})
+			}
+
+			c.Set(apiKeyContextKey, key)
+
+			return next(c)
+		}
+	}
+}
+
+func (s *Server) GetAllAPIKeys(c echo.Context) error {
+	keys, err := s.apiKeyStorer.GetAllAPIKeys(c.Request().Context())
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(200, keys)
+}
+
+// CreateAPIKey issues a new key, the token is only returned in this response
+func (s *Server) CreateAPIKey(c echo.Context) error {
+	var req createAPIKeyRequest
+	if err := c.Bind(&req); err != nil || req.Name == "" || len(req.Scopes) == 0 {
+		return c.JSON(400, map[string]string{"error": "invalid request"})
+	}
+
+	token, key, err := NewAPIKey(req.Name, req.Scopes)
+	if errors.Is(err, ErrInvalidScope) {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	err = s.apiKeyStorer.CreateAPIKey(c.Request().Context(), *key)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	slog.InfoContext(c.Request().Context(), "api key issued", slog.String("api_key_id", key.Id), slog.Any("scopes", key.Scopes))
+
+	return c.JSON(201, createAPIKeyResponse{Token: token, APIKey: *key})
+}
+
+func (s *Server) RevokeAPIKey(c echo.Context) error {
+	id := c.Param("id")
+
+	err := s.apiKeyStorer.RevokeAPIKey(c.Request().Context(), id)
+	if errors.Is(err, ErrAPIKeyNotFound) {
+		return c.JSON(404, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	slog.InfoContext(c.Request().Context(), "api key revoked", slog.String("api_key_id", id))
+
+	return c.JSON(200, map[string]string{"message": "api key revoked"})
+}
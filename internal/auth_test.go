@@ -0,0 +1,140 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newAuthTestServer(t *testing.T) (*Server, *InMemoryAPIKeyStorer, *MockMessageStorer) {
+	t.Helper()
+
+	mockStore := NewMockMessageStorer(t)
+	apiKeyStorer := NewInMemoryAPIKeyStorer()
+	cfg := HTTPConfig{EnableSend: true, Auth: AuthConfig{Enabled: true}}
+
+	return NewServer(cfg, mockStore, NewMockMessageSender(t), apiKeyStorer, NewInMemoryImageStorer(), NewInMemoryHistoryStorer(), NewInMemoryRosterStorer()), apiKeyStorer, mockStore
+}
+
+func issueAPIKey(t *testing.T, storer APIKeyStorer, scopes ...APIKeyScope) (string, *APIKey) {
+	t.Helper()
+
+	token, key, err := NewAPIKey("test", scopes)
+	require.NoError(t, err)
+	require.NoError(t, storer.CreateAPIKey(context.Background(), *key))
+
+	return token, key
+}
+
+func TestRequireScope(t *testing.T) {
+	server, apiKeyStorer, mockStore := newAuthTestServer(t)
+	mockStore.On("GetAllMessages", mock.Anything).Return([]Message{}, nil)
+
+	readToken, _ := issueAPIKey(t, apiKeyStorer, ScopeMessagesRead)
+	sendToken, _ := issueAPIKey(t, apiKeyStorer, ScopeSend)
+	adminToken, _ := issueAPIKey(t, apiKeyStorer, ScopeAdmin)
+	revokedToken, revokedKey := issueAPIKey(t, apiKeyStorer, ScopeMessagesRead)
+	require.NoError(t, apiKeyStorer.RevokeAPIKey(context.Background(), revokedKey.Id))
+
+	tests := []struct {
+		name   string
+		header string
+		token  string
+		status int
+	}{
+		{name: "missing key", status: http.StatusUnauthorized},
+		{name: "unknown key", header: "X-API-Key", token: "wb_unknown", status: http.StatusUnauthorized},
+		{name: "revoked key", header: "X-API-Key", token: revokedToken, status: http.StatusUnauthorized},
+		{name: "missing scope", header: "X-API-Key", token: sendToken, status: http.StatusForbidden},
+		{name: "api key header", header: "X-API-Key", token: readToken, status: http.StatusOK},
+		{name: "bearer token", header: echo.HeaderAuthorization, token: "Bearer " + readToken, status: http.StatusOK},
+		{name: "admin grants everything", header: "X-API-Key", token: adminToken, status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/messages/", nil)
+			if tt.header != "" {
+				req.Header.Set(tt.header, tt.token)
+			}
+			rec := httptest.NewRecorder()
+
+			server.echoServer.ServeHTTP(rec, req)
+
+			assert.Equal(t, tt.status, rec.Code)
+		})
+	}
+}
+
+func TestRequireScopeDisabled(t *testing.T) {
+	mockStore := NewMockMessageStorer(t)
+	mockStore.On("GetAllMessages", mock.Anything).Return([]Message{}, nil)
+
+	server := NewServer(HTTPConfig{}, mockStore, NewMockMessageSender(t), NewMockAPIKeyStorer(t), NewInMemoryImageStorer(), NewInMemoryHistoryStorer(), NewInMemoryRosterStorer())
+
+	req := httptest.NewRequest(http.MethodGet, "/messages/", nil)
+	rec := httptest.NewRecorder()
+
+	server.echoServer.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestAdminIssueAndRevokeAPIKey(t *testing.T) {
+	server, apiKeyStorer, _ := newAuthTestServer(t)
+	adminToken, _ := issueAPIKey(t, apiKeyStorer, ScopeAdmin)
+
+	body := `{"name":"ci","scopes":["broken:write"]}`
+	req := httptest.NewRequest(http.MethodPost, "/admin/keys", bytes.NewBufferString(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("X-API-Key", adminToken)
+	rec := httptest.NewRecorder()
+
+	server.echoServer.ServeHTTP(rec, req)
+
+	require.Equal(t, http.StatusCreated, rec.Code)
+
+	var created createAPIKeyResponse
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.NotEmpty(t, created.Token)
+	assert.NotContains(t, rec.Body.String(), HashAPIKey(created.Token))
+
+	stored, err := apiKeyStorer.GetAPIKeyByHash(context.Background(), HashAPIKey(created.Token))
+	require.NoError(t, err)
+	assert.True(t, stored.HasScope(ScopeBrokenWrite))
+	assert.False(t, stored.HasScope(ScopeSend))
+
+	req = httptest.NewRequest(http.MethodDelete, "/admin/keys/"+created.APIKey.Id, nil)
+	req.Header.Set("X-API-Key", adminToken)
+	rec = httptest.NewRecorder()
+
+	server.echoServer.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	stored, err = apiKeyStorer.GetAPIKeyByHash(context.Background(), HashAPIKey(created.Token))
+	require.NoError(t, err)
+	assert.True(t, stored.Revoked())
+}
+
+func TestAdminCreateAPIKeyInvalidScope(t *testing.T) {
+	server, apiKeyStorer, _ := newAuthTestServer(t)
+	adminToken, _ := issueAPIKey(t, apiKeyStorer, ScopeAdmin)
+
+	req := httptest.NewRequest(http.MethodPost, "/admin/keys", bytes.NewBufferString(`{"name":"ci","scopes":["root"]}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set("X-API-Key", adminToken)
+	rec := httptest.NewRecorder()
+
+	server.echoServer.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,47 @@
+package internal
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// Background runs the sends that outlive the request asking for them, the
+// chat commands have to be answered in a few seconds while the platforms may
+// take longer
+type Background struct {
+	timeout time.Duration
+	wg      sync.WaitGroup
+}
+
+func NewBackground(timeout time.Duration) *Background {
+	return &Background{
+		timeout: timeout,
+	}
+}
+
+// Go runs the task with the values of ctx but not its cancellation, which
+// comes with the answer to the request. Failures are only logged, nobody is
+// waiting for them
+func (b *Background) Go(ctx context.Context, task string, fn func(ctx context.Context) error) {
+	b.wg.Add(1)
+
+	go func() {
+		defer b.wg.Done()
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), b.timeout)
+		defer cancel()
+
+		err := fn(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "background task failed", slog.String("task", task), slog.Any("error", err))
+		}
+	}()
+}
+
+// Wait blocks until the running tasks are done, so a shutdown doesn't cut
+// them
+func (b *Background) Wait() {
+	b.wg.Wait()
+}
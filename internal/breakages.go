@@ -0,0 +1,168 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type Breakage struct {
+	Id       string    `json:"id"`
+	Name     string    `json:"name"`
+	Motive   string    `json:"motive"`
+	BrokenAt time.Time `json:"broken_at"`
+}
+
+type LeaderboardEntry struct {
+	Name         string    `json:"name"`
+	Breakages    int       `json:"breakages"`
+	LastBrokenAt time.Time `json:"last_broken_at"`
+}
+
+type BreakageTracker interface {
+	RecordBreakage(ctx context.Context, breakage Breakage) error
+	GetBreakages(ctx context.Context, since time.Time, until time.Time) ([]Breakage, error)
+	GetLeaderboard(ctx context.Context) ([]LeaderboardEntry, error)
+}
+
+type InMemoryBreakageTracker struct {
+	mu        sync.RWMutex
+	breakages []Breakage
+}
+
+var (
+	_ BreakageTracker = (*InMemoryBreakageTracker)(nil)
+)
+
+func NewInMemoryBreakageTracker() *InMemoryBreakageTracker {
+	return &InMemoryBreakageTracker{}
+}
+
+func (t *InMemoryBreakageTracker) RecordBreakage(ctx context.Context, breakage Breakage) error {
+	if breakage.Id == "" {
+		breakage.Id = uuid.NewString()
+	}
+
+	if breakage.BrokenAt.IsZero() {
+		breakage.BrokenAt = time.Now()
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.breakages = append(t.breakages, breakage)
+
+	return nil
+}
+
+// GetBreakages returns the breakages that happened in the [since, until) interval
+func (t *InMemoryBreakageTracker) GetBreakages(ctx context.Context, since time.Time, until time.Time) ([]Breakage, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	var breakages []Breakage
+	for _, b := range t.breakages {
+		if b.BrokenAt.Before(since) || !b.BrokenAt.Before(until) {
+			continue
+		}
+		breakages = append(breakages, b)
+	}
+
+	return breakages, nil
+}
+
+func (t *InMemoryBreakageTracker) GetLeaderboard(ctx context.Context) ([]LeaderboardEntry, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+
+	return NewLeaderboard(t.breakages), nil
+}
+
+// NewLeaderboard ranks people by number of breakages, ties are won by whoever
+// broke things most recently
+func NewLeaderboard(breakages []Breakage) []LeaderboardEntry {
+	entries := make(map[string]*LeaderboardEntry)
+	for _, b := range breakages {
+		entry, ok := entries[b.Name]
+		if !ok {
+			entry = &LeaderboardEntry{Name: b.Name}
+			entries[b.Name] = entry
+		}
+
+		entry.Breakages++
+		if b.BrokenAt.After(entry.LastBrokenAt) {
+			entry.LastBrokenAt = b.BrokenAt
+		}
+	}
+
+	leaderboard := make([]LeaderboardEntry, 0, len(entries))
+	for _, entry := range entries {
+		leaderboard = append(leaderboard, *entry)
+	}
+
+	sort.Slice(leaderboard, func(i, j int) bool {
+		if leaderboard[i].Breakages != leaderboard[j].Breakages {
+			return leaderboard[i].Breakages > leaderboard[j].Breakages
+		}
+		return leaderboard[i].LastBrokenAt.After(leaderboard[j].LastBrokenAt)
+	})
+
+	return leaderboard
+}
+
+// NewBrokenMessage builds the broken message card data for a new breakage,
+// measuring the time since the last breakage recorded by the tracker
+func NewBrokenMessage(
+	ctx context.Context,
+	breakageTracker BreakageTracker,
+	name string,
+	motive string,
+	now time.Time,
+) (BrokenMessage, error) {
+	message := BrokenMessage{
+		Id:              uuid.NewString(),
+		Name:            name,
+		Motive:          motive,
+		TimeSinceBroken: "Primeira quebra registrada",
+		DayOfBreakage:   now.Format("02/01/2006"),
+	}
+
+	leaderboard, err := breakageTracker.GetLeaderboard(ctx)
+	if err != nil {
+		return BrokenMessage{}, err
+	}
+
+	var lastBrokenAt time.Time
+	for _, entry := range leaderboard {
+		if entry.LastBrokenAt.After(lastBrokenAt) {
+			lastBrokenAt = entry.LastBrokenAt
+		}
+	}
+
+	if !lastBrokenAt.IsZero() {
+		message.TimeSinceBroken = FormatElapsed(now.Sub(lastBrokenAt))
+	}
+
+	return message, nil
+}
+
+// FormatElapsed formats a duration in a human friendly way, using the biggest
+// unit that fits
+func FormatElapsed(d time.Duration) string {
+	switch {
+	case d >= 48*time.Hour:
+		return fmt.Sprintf("%d dias", int(d.Hours()/24))
+	case d >= 24*time.Hour:
+		return "1 dia"
+	case d >= 2*time.Hour:
+		return fmt.Sprintf("%d horas", int(d.Hours()))
+	case d >= time.Hour:
+		return "1 hora"
+	default:
+		return fmt.Sprintf("%d minutos", int(d.Minutes()))
+	}
+}
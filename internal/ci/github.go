@@ -0,0 +1,192 @@
+package ci
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	githubEventHeader     = "X-GitHub-Event"
+	githubDeliveryHeader  = "X-GitHub-Delivery"
+	githubSignatureHeader = "X-Hub-Signature-256"
+
+	githubEventWorkflowRun = "workflow_run"
+	githubEventPing        = "ping"
+)
+
+var githubFailedConclusions = []string{"failure", "timed_out", "startup_failure"}
+
+type githubWorkflowRunEvent struct {
+	Action      string `json:"action"`
+	WorkflowRun struct {
+		ID           int64  `json:"id"`
+		Name         string `json:"name"`
+		DisplayTitle string `json:"display_title"`
+		HeadBranch   string `json:"head_branch"`
+		Conclusion   string `json:"conclusion"`
+		HTMLURL      string `json:"html_url"`
+		HeadCommit   struct {
+			Message string `json:"message"`
+			Author  struct {
+				Name  string `json:"name"`
+				Email string `json:"email"`
+			} `json:"author"`
+		} `json:"head_commit"`
+		Actor struct {
+			Login string `json:"login"`
+		} `json:"actor"`
+	} `json:"workflow_run"`
+	Repository struct {
+		FullName string `json:"full_name"`
+	} `json:"repository"`
+}
+
+type githubJobs struct {
+	Jobs []struct {
+		Name       string `json:"name"`
+		Conclusion string `json:"conclusion"`
+		Steps      []struct {
+			Name       string `json:"name"`
+			Conclusion string `json:"conclusion"`
+		} `json:"steps"`
+	} `json:"jobs"`
+}
+
+// verifyGitHubSignature checks the X-Hub-Signature-256 header GitHub computes
+// over the raw body with the webhook secret
+func verifyGitHubSignature(secret string, signature string, body []byte) bool {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	expected := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+
+	return hmac.Equal([]byte(expected), []byte(signature))
+}
+
+// HandleGitHub reports failed workflow_run events, everything else is ignored
+func (h *WebhookHandler) HandleGitHub(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "invalid request"})
+	}
+
+	if h.githubSecret == "" || !verifyGitHubSignature(h.githubSecret, c.Request().Header.Get(githubSignatureHeader), body) {
+		return c.JSON(401, map[string]string{"error": "invalid signature"})
+	}
+
+	switch c.Request().Header.Get(githubEventHeader) {
+	case githubEventPing:
+		return c.JSON(200, map[string]string{"message": "pong"})
+	case githubEventWorkflowRun:
+	default:
+		return c.JSON(202, map[string]string{"message": "event ignored"})
+	}
+
+	var event githubWorkflowRunEvent
+	if err := json.Unmarshal(body, &event); err != nil {
+		return c.JSON(400, map[string]string{"error": "invalid request"})
+	}
+
+	run := event.WorkflowRun
+	if event.Action != "completed" || !slices.Contains(githubFailedConclusions, run.Conclusion) {
+		return c.JSON(202, map[string]string{"message": "event ignored"})
+	}
+
+	delivery := c.Request().Header.Get(githubDeliveryHeader)
+	if !h.deliveries.claim(delivery, time.Now()) {
+		return c.JSON(200, map[string]string{"message": "delivery already processed"})
+	}
+
+	workflow := run.Name
+	job, err := h.failedJob(ctx, event.Repository.FullName, run.ID)
+	if err != nil {
+		slog.WarnContext(ctx, "failed to find the failed github job, using the workflow", slog.Any("error", err))
+	}
+	if job != "" {
+		workflow += " / " + job
+	}
+
+	name := h.resolveMember(ctx, run.Actor.Login, run.HeadCommit.Author.Email, run.HeadCommit.Author.Name)
+	motive := fmt.Sprintf("Workflow %s falhou em %s: %s", workflow, run.HeadBranch, firstLine(run.HeadCommit.Message))
+
+	brokenMessage, err := h.reportBreakage(ctx, delivery, name, motive)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to report github breakage", slog.Any("error", err))
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(200, brokenMessage)
+}
+
+// failedJob names the first failed job of the run along with its failed
+// step, as "<job> (<step>)". It is empty when the run lists no failed job.
+// The jobs are read from the configured API, never from the urls of the
+// payload, so the token doesn't leave to another host
+func (h *WebhookHandler) failedJob(ctx context.Context, repository string, runID int64) (string, error) {
+	if h.githubAPIURL == "" || repository == "" || runID == 0 {
+		return "", nil
+	}
+
+	u, err := url.JoinPath(h.githubAPIURL, "repos", repository, "actions", "runs", strconv.FormatInt(runID, 10), "jobs")
+	if err != nil {
+		return "", err
+	}
+	u += "?filter=latest"
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u, nil)
+	if err != nil {
+		return "", err
+	}
+
+	req.Header.Set("Accept", "application/vnd.github+json")
+	if h.githubToken != "" {
+		req.Header.Set("Authorization", "Bearer "+h.githubToken)
+	}
+
+	resp, err := h.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("unexpected status code %d", resp.StatusCode)
+	}
+
+	var jobs githubJobs
+	err = json.NewDecoder(resp.Body).Decode(&jobs)
+	if err != nil {
+		return "", err
+	}
+
+	for _, job := range jobs.Jobs {
+		if !slices.Contains(githubFailedConclusions, job.Conclusion) {
+			continue
+		}
+
+		for _, step := range job.Steps {
+			if slices.Contains(githubFailedConclusions, step.Conclusion) {
+				return fmt.Sprintf("%s (%s)", job.Name, step.Name), nil
+			}
+		}
+
+		return job.Name, nil
+	}
+
+	return "", nil
+}
@@ -0,0 +1,89 @@
+package ci
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	gitlabTokenHeader     = "X-Gitlab-Token"
+	gitlabEventUUIDHeader = "X-Gitlab-Event-UUID"
+
+	gitlabObjectKindPipeline = "pipeline"
+	gitlabStatusFailed       = "failed"
+)
+
+type gitlabPipelineEvent struct {
+	ObjectKind       string `json:"object_kind"`
+	ObjectAttributes struct {
+		Ref    string `json:"ref"`
+		Status string `json:"status"`
+	} `json:"object_attributes"`
+	User struct {
+		Name     string `json:"name"`
+		Username string `json:"username"`
+		Email    string `json:"email"`
+	} `json:"user"`
+	Commit struct {
+		Message string `json:"message"`
+		Author  struct {
+			Name  string `json:"name"`
+			Email string `json:"email"`
+		} `json:"author"`
+	} `json:"commit"`
+	Builds []struct {
+		Name          string `json:"name"`
+		Stage         string `json:"stage"`
+		Status        string `json:"status"`
+		FailureReason string `json:"failure_reason"`
+	} `json:"builds"`
+}
+
+// motive describes the first failed job of the pipeline
+func (e gitlabPipelineEvent) motive() string {
+	for _, b := range e.Builds {
+		if b.Status == gitlabStatusFailed {
+			return fmt.Sprintf("Job %s (%s) falhou em %s: %s", b.Name, b.Stage, e.ObjectAttributes.Ref, firstLine(e.Commit.Message))
+		}
+	}
+
+	return fmt.Sprintf("Pipeline falhou em %s: %s", e.ObjectAttributes.Ref, firstLine(e.Commit.Message))
+}
+
+// HandleGitLab reports failed pipeline events, everything else is ignored
+func (h *WebhookHandler) HandleGitLab(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	token := c.Request().Header.Get(gitlabTokenHeader)
+	if h.gitlabToken == "" || subtle.ConstantTimeCompare([]byte(token), []byte(h.gitlabToken)) != 1 {
+		return c.JSON(401, map[string]string{"error": "invalid token"})
+	}
+
+	var event gitlabPipelineEvent
+	if err := c.Bind(&event); err != nil {
+		return c.JSON(400, map[string]string{"error": "invalid request"})
+	}
+
+	if event.ObjectKind != gitlabObjectKindPipeline || event.ObjectAttributes.Status != gitlabStatusFailed {
+		return c.JSON(202, map[string]string{"message": "event ignored"})
+	}
+
+	delivery := c.Request().Header.Get(gitlabEventUUIDHeader)
+	if !h.deliveries.claim(delivery, time.Now()) {
+		return c.JSON(200, map[string]string{"message": "delivery already processed"})
+	}
+
+	name := h.resolveMember(ctx, event.User.Username, event.Commit.Author.Email, event.User.Email, event.Commit.Author.Name, event.User.Name)
+
+	brokenMessage, err := h.reportBreakage(ctx, delivery, name, event.motive())
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to report gitlab breakage", slog.Any("error", err))
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(200, brokenMessage)
+}
@@ -0,0 +1,156 @@
+package ci
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/taldoflemis/wilson-bot/internal"
+)
+
+// WebhookHandler turns CI failure notifications into breakage reports
+type WebhookHandler struct {
+	githubSecret    string
+	githubToken     string
+	githubAPIURL    string
+	gitlabToken     string
+	rosterStorer    internal.RosterStorer
+	messageSender   internal.MessageSender
+	breakageTracker internal.BreakageTracker
+	deliveries      *deliveryCache
+	httpClient      *http.Client
+}
+
+var (
+	_ internal.RouteRegisterer = (*WebhookHandler)(nil)
+)
+
+func NewWebhookHandler(
+	cfg internal.CIConfig,
+	messageSender internal.MessageSender,
+	breakageTracker internal.BreakageTracker,
+	rosterStorer internal.RosterStorer,
+) *WebhookHandler {
+	return &WebhookHandler{
+		githubSecret:    cfg.GitHubSecret,
+		githubToken:     cfg.GitHubToken,
+		githubAPIURL:    cfg.GitHubAPIURL,
+		gitlabToken:     cfg.GitLabToken,
+		rosterStorer:    rosterStorer,
+		messageSender:   messageSender,
+		breakageTracker: breakageTracker,
+		deliveries:      newDeliveryCache(time.Hour),
+		httpClient:      internal.NewTracedHTTPClient(10 * time.Second),
+	}
+}
+
+func (h *WebhookHandler) RegisterRoutes(g *echo.Group) {
+	g.POST("/webhook/github", h.HandleGitHub, internal.TriggerMiddleware(internal.TriggerWebhook))
+	g.POST("/webhook/gitlab", h.HandleGitLab, internal.TriggerMiddleware(internal.TriggerWebhook))
+}
+
+// resolveMember maps the first candidate (login, username or email) known in
+// the roster, by name or alias, to the team member name, falling back to the
+// first non empty candidate
+func (h *WebhookHandler) resolveMember(ctx context.Context, candidates ...string) string {
+	for _, c := range candidates {
+		if c == "" {
+			continue
+		}
+
+		member, err := internal.FindTeamMember(ctx, h.rosterStorer, c)
+		if err == nil {
+			return member.Name
+		}
+		if !errors.Is(err, internal.ErrTeamMemberNotFound) {
+			slog.WarnContext(ctx, "failed to look up ci author", slog.String("author", c), slog.Any("error", err))
+			break
+		}
+	}
+
+	for _, c := range candidates {
+		if c != "" {
+			return c
+		}
+	}
+
+	return "Desconhecido"
+}
+
+// reportBreakage sends the card of the breakage. The delivery is only
+// released, for the redelivery to retry it, when the card can't be built,
+// once handed to the sender the breakage is recorded even if a platform fails
+// and a redelivery would count it twice
+func (h *WebhookHandler) reportBreakage(ctx context.Context, delivery string, name string, motive string) (*internal.BrokenMessage, error) {
+	brokenMessage, err := internal.NewBrokenMessage(ctx, h.breakageTracker, name, motive, time.Now())
+	if err != nil {
+		h.deliveries.release(delivery)
+		return nil, err
+	}
+
+	err = h.messageSender.SendBrokenMessage(ctx, brokenMessage)
+	if err != nil {
+		return nil, err
+	}
+
+	slog.InfoContext(ctx, "ci breakage reported", slog.String("name", name), slog.String("motive", motive))
+
+	return &brokenMessage, nil
+}
+
+func firstLine(s string) string {
+	line, _, _ := strings.Cut(s, "\n")
+	return strings.TrimSpace(line)
+}
+
+// deliveryCache remembers the delivery ids already processed, both GitHub and
+// GitLab redeliver webhooks that timed out
+type deliveryCache struct {
+	mu   sync.Mutex
+	ttl  time.Duration
+	seen map[string]time.Time
+}
+
+func newDeliveryCache(ttl time.Duration) *deliveryCache {
+	return &deliveryCache{
+		ttl:  ttl,
+		seen: make(map[string]time.Time),
+	}
+}
+
+// claim reports if the delivery wasn't seen yet and marks it as seen
+func (d *deliveryCache) claim(id string, now time.Time) bool {
+	if id == "" {
+		return true
+	}
+
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	for delivery, seenAt := range d.seen {
+		if now.Sub(seenAt) > d.ttl {
+			delete(d.seen, delivery)
+		}
+	}
+
+	if _, ok := d.seen[id]; ok {
+		return false
+	}
+
+	d.seen[id] = now
+
+	return true
+}
+
+func (d *deliveryCache) release(id string) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	delete(d.seen, id)
+}
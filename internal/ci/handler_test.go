@@ -0,0 +1,253 @@
+package ci
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/taldoflemis/wilson-bot/internal"
+)
+
+var testConfig = internal.CIConfig{
+	GitHubSecret: "github-secret",
+	GitLabToken:  "gitlab-token",
+}
+
+var testRoster = internal.NewInMemoryRosterStorer(
+	internal.TeamMember{Id: "wilson", Name: "Wilson", Aliases: []string{"wilson-gh", "wilson@example.com"}},
+	internal.TeamMember{Id: "flemis", Name: "Flemis"},
+)
+
+func loadFixture(t *testing.T, name string) []byte {
+	t.Helper()
+
+	body, err := os.ReadFile(filepath.Join("testdata", name))
+	require.NoError(t, err)
+
+	return body
+}
+
+func newGitHubRequest(event string, delivery string, body []byte, secret string) *http.Request {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook/github", bytes.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(githubEventHeader, event)
+	req.Header.Set(githubDeliveryHeader, delivery)
+	req.Header.Set(githubSignatureHeader, "sha256="+hex.EncodeToString(mac.Sum(nil)))
+
+	return req
+}
+
+func newGitLabRequest(body []byte, token string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/webhook/gitlab", bytes.NewReader(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(gitlabTokenHeader, token)
+
+	return req
+}
+
+func TestHandleGitHubWorkflowRunFailure(t *testing.T) {
+	e := echo.New()
+	tracker := internal.NewInMemoryBreakageTracker()
+	mockSender := internal.NewMockMessageSender(t)
+	mockSender.On("SendBrokenMessage", mock.Anything, mock.MatchedBy(func(m internal.BrokenMessage) bool {
+		return m.Name == "Wilson" && m.Motive == "Workflow CI falhou em main: Subiu sem testar"
+	})).Return(nil).Once()
+
+	handler := NewWebhookHandler(testConfig, mockSender, tracker, testRoster)
+	body := loadFixture(t, "github_workflow_run_failure.json")
+
+	rec := httptest.NewRecorder()
+	err := handler.HandleGitHub(e.NewContext(newGitHubRequest("workflow_run", "delivery-1", body, "github-secret"), rec))
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// GitHub redelivering the same event must not report it twice
+	rec = httptest.NewRecorder()
+	err = handler.HandleGitHub(e.NewContext(newGitHubRequest("workflow_run", "delivery-1", body, "github-secret"), rec))
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "already processed")
+}
+
+func TestHandleGitHubIgnoresSuccessfulRuns(t *testing.T) {
+	e := echo.New()
+	handler := NewWebhookHandler(testConfig, internal.NewMockMessageSender(t), internal.NewInMemoryBreakageTracker(), testRoster)
+	body := loadFixture(t, "github_workflow_run_success.json")
+
+	rec := httptest.NewRecorder()
+	err := handler.HandleGitHub(e.NewContext(newGitHubRequest("workflow_run", "delivery-2", body, "github-secret"), rec))
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+}
+
+func TestHandleGitHubInvalidSignature(t *testing.T) {
+	e := echo.New()
+	handler := NewWebhookHandler(testConfig, internal.NewMockMessageSender(t), internal.NewInMemoryBreakageTracker(), testRoster)
+	body := loadFixture(t, "github_workflow_run_failure.json")
+
+	rec := httptest.NewRecorder()
+	err := handler.HandleGitHub(e.NewContext(newGitHubRequest("workflow_run", "delivery-3", body, "wrong-secret"), rec))
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestHandleGitLabPipelineFailed(t *testing.T) {
+	e := echo.New()
+	tracker := internal.NewInMemoryBreakageTracker()
+	mockSender := internal.NewMockMessageSender(t)
+	mockSender.On("SendBrokenMessage", mock.Anything, mock.MatchedBy(func(m internal.BrokenMessage) bool {
+		return m.Name == "Flemis" && m.Motive == "Job integration-tests (test) falhou em main: Esqueceu a migration"
+	})).Return(nil).Once()
+
+	handler := NewWebhookHandler(testConfig, mockSender, tracker, testRoster)
+	body := loadFixture(t, "gitlab_pipeline_failed.json")
+
+	rec := httptest.NewRecorder()
+	err := handler.HandleGitLab(e.NewContext(newGitLabRequest(body, "gitlab-token"), rec))
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestHandleGitLabInvalidToken(t *testing.T) {
+	e := echo.New()
+	handler := NewWebhookHandler(testConfig, internal.NewMockMessageSender(t), internal.NewInMemoryBreakageTracker(), testRoster)
+	body := loadFixture(t, "gitlab_pipeline_failed.json")
+
+	rec := httptest.NewRecorder()
+	err := handler.HandleGitLab(e.NewContext(newGitLabRequest(body, "wrong-token"), rec))
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestBreakageTrackerUpdatedThroughRecordingSender(t *testing.T) {
+	e := echo.New()
+	tracker := internal.NewInMemoryBreakageTracker()
+	mockSender := internal.NewMockMessageSender(t)
+	mockSender.On("SendBrokenMessage", mock.Anything, mock.Anything).Return(nil)
+
+	sender := internal.NewRecordingMessageSender(mockSender, internal.NewInMemoryHistoryStorer(), tracker, nil)
+	handler := NewWebhookHandler(testConfig, sender, tracker, testRoster)
+
+	rec := httptest.NewRecorder()
+	err := handler.HandleGitLab(e.NewContext(newGitLabRequest(loadFixture(t, "gitlab_pipeline_failed.json"), "gitlab-token"), rec))
+	require.NoError(t, err)
+
+	leaderboard, err := tracker.GetLeaderboard(t.Context())
+	require.NoError(t, err)
+	require.Len(t, leaderboard, 1)
+	assert.Equal(t, "Flemis", leaderboard[0].Name)
+}
+
+func TestHandleGitHubNamesTheFailedJob(t *testing.T) {
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repos/taldoflemis/wilson-bot/actions/runs/30433642/jobs", r.URL.Path)
+		assert.Equal(t, "latest", r.URL.Query().Get("filter"))
+		assert.Equal(t, "Bearer github-token", r.Header.Get("Authorization"))
+		w.Write([]byte(`{"jobs":[
+			{"name":"lint","conclusion":"success","steps":[{"name":"Run lint","conclusion":"success"}]},
+			{"name":"test","conclusion":"failure","steps":[{"name":"Checkout","conclusion":"success"},{"name":"Run tests","conclusion":"failure"}]}
+		]}`))
+	}))
+	defer api.Close()
+
+	forged := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("the token was sent to the jobs_url of the payload")
+	}))
+	defer forged.Close()
+
+	var event map[string]any
+	require.NoError(t, json.Unmarshal(loadFixture(t, "github_workflow_run_failure.json"), &event))
+	event["workflow_run"].(map[string]any)["jobs_url"] = forged.URL
+	body, err := json.Marshal(event)
+	require.NoError(t, err)
+
+	e := echo.New()
+	mockSender := internal.NewMockMessageSender(t)
+	mockSender.On("SendBrokenMessage", mock.Anything, mock.MatchedBy(func(m internal.BrokenMessage) bool {
+		return m.Motive == "Workflow CI / test (Run tests) falhou em main: Subiu sem testar"
+	})).Return(nil).Once()
+
+	cfg := testConfig
+	cfg.GitHubToken = "github-token"
+	cfg.GitHubAPIURL = api.URL
+	handler := NewWebhookHandler(cfg, mockSender, internal.NewInMemoryBreakageTracker(), testRoster)
+
+	rec := httptest.NewRecorder()
+	err = handler.HandleGitHub(e.NewContext(newGitHubRequest("workflow_run", "delivery-jobs", body, "github-secret"), rec))
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestHandleGitHubRetriesUnreportedDelivery(t *testing.T) {
+	e := echo.New()
+	tracker := internal.NewMockBreakageTracker(t)
+	tracker.On("GetLeaderboard", mock.Anything).Return(nil, errors.New("boom")).Once()
+	tracker.On("GetLeaderboard", mock.Anything).Return(nil, nil).Once()
+
+	mockSender := internal.NewMockMessageSender(t)
+	mockSender.On("SendBrokenMessage", mock.Anything, mock.Anything).Return(nil).Once()
+
+	handler := NewWebhookHandler(testConfig, mockSender, tracker, testRoster)
+	body := loadFixture(t, "github_workflow_run_failure.json")
+
+	rec := httptest.NewRecorder()
+	err := handler.HandleGitHub(e.NewContext(newGitHubRequest("workflow_run", "delivery-retry", body, "github-secret"), rec))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	// the card was never built, the redelivery reports it
+	rec = httptest.NewRecorder()
+	err = handler.HandleGitHub(e.NewContext(newGitHubRequest("workflow_run", "delivery-retry", body, "github-secret"), rec))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestHandleGitHubKeepsFailedSendClaimed(t *testing.T) {
+	e := echo.New()
+	tracker := internal.NewInMemoryBreakageTracker()
+	mockSender := internal.NewMockMessageSender(t)
+	mockSender.On("SendBrokenMessage", mock.Anything, mock.Anything).Return(errors.New("boom")).Once()
+
+	sender := internal.NewRecordingMessageSender(mockSender, internal.NewInMemoryHistoryStorer(), tracker, nil)
+	handler := NewWebhookHandler(testConfig, sender, tracker, testRoster)
+	body := loadFixture(t, "github_workflow_run_failure.json")
+
+	rec := httptest.NewRecorder()
+	err := handler.HandleGitHub(e.NewContext(newGitHubRequest("workflow_run", "delivery-failed", body, "github-secret"), rec))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+
+	// the breakage is already recorded, the redelivery must not count it again
+	rec = httptest.NewRecorder()
+	err = handler.HandleGitHub(e.NewContext(newGitHubRequest("workflow_run", "delivery-failed", body, "github-secret"), rec))
+	assert.NoError(t, err)
+	assert.Contains(t, rec.Body.String(), "already processed")
+
+	leaderboard, err := tracker.GetLeaderboard(t.Context())
+	require.NoError(t, err)
+	require.Len(t, leaderboard, 1)
+	assert.Equal(t, 1, leaderboard[0].Breakages)
+}
@@ -5,6 +5,7 @@ import (
 	_ "embed"
 	"log/slog"
 	"strings"
+	"time"
 
 	"github.com/knadh/koanf/parsers/toml"
 	"github.com/knadh/koanf/providers/env"
@@ -21,31 +22,203 @@ var k = koanf.New(".")
 //go:embed base_config.toml
 var baseConfig []byte
 
+type AuthConfig struct {
+	Enabled      bool   `koanf:"enabled"`
+	AdminKeyHash string `koanf:"admin_key_hash"`
+}
+
+type WebhookSignatureConfig struct {
+	Enabled bool              `koanf:"enabled"`
+	MaxAge  time.Duration     `koanf:"max_age"`
+	Secrets map[string]string `koanf:"secrets"`
+
+	// BodyOnly are the sources signing the body without a timestamp
+	BodyOnly []string `koanf:"body_only"`
+}
+
+type AlertmanagerConfig struct {
+	Roast         bool   `koanf:"roast"`
+	RoastTag      string `koanf:"roast_tag"`
+	OnCallLabel   string `koanf:"on_call_label"`
+	DefaultOnCall string `koanf:"default_on_call"`
+}
+
+type MetricsConfig struct {
+	Enabled bool   `koanf:"enabled"`
+	Path    string `koanf:"path"`
+}
+
 type HTTPConfig struct {
-	Port       string `koanf:"port"`
-	Prefix     string `koanf:"prefix"`
-	Host       string `koanf:"host"`
-	EnableSend bool   `koanf:"enable_send"`
+	Port             string                 `koanf:"port"`
+	Prefix           string                 `koanf:"prefix"`
+	Host             string                 `koanf:"host"`
+	EnableSend       bool                   `koanf:"enable_send"`
+	Auth             AuthConfig             `koanf:"auth"`
+	WebhookSignature WebhookSignatureConfig `koanf:"webhook_signature"`
+	Alertmanager     AlertmanagerConfig     `koanf:"alertmanager"`
+	Metrics          MetricsConfig          `koanf:"metrics"`
 }
 
 type CronConfig struct {
-	Enabled bool `koanf:"enabled"`
+	Enabled    bool   `koanf:"enabled"`
 	CronString string `koanf:"cron_string"`
+	ImageID    string `koanf:"image_id"`
+
+	// Rotation makes every daily message target the next roster member
+	Rotation bool `koanf:"rotation"`
+	// RotationStateFile keeps the rotation across restarts, in memory when
+	// empty
+	RotationStateFile string `koanf:"rotation_state_file"`
+}
+
+type DigestConfig struct {
+	Enabled           bool   `koanf:"enabled"`
+	WeeklyCronString  string `koanf:"weekly_cron_string"`
+	MonthlyCronString string `koanf:"monthly_cron_string"`
+	TopTags           int    `koanf:"top_tags"`
+	ImageID           string `koanf:"image_id"`
 }
 
 type GoogleChatConfig struct {
-	WebhookURL string `koanf:"webhook_url"`
+	Enabled     bool      `koanf:"enabled"`
+	WebhookURL  string    `koanf:"webhook_url"`
+	DryRun      bool      `koanf:"dry_run"`
+	TemplateDir string    `koanf:"template_dir"`
+	LimitMode   LimitMode `koanf:"limit_mode"`
+
+	Threads GoogleChatThreadsConfig `koanf:"threads"`
+}
+
+// GoogleChatThreadsConfig picks the thread strategy of every kind of card
+type GoogleChatThreadsConfig struct {
+	Message     ThreadStrategy `koanf:"message"`
+	Broken      ThreadStrategy `koanf:"broken"`
+	Digest      ThreadStrategy `koanf:"digest"`
+	Alert       ThreadStrategy `koanf:"alert"`
+	StandingKey string         `koanf:"standing_key"`
+}
+
+type GoogleChatEventsConfig struct {
+	Enabled         bool   `koanf:"enabled"`
+	JWKSURL         string `koanf:"jwks_url"`
+	Issuer          string `koanf:"issuer"`
+	Audience        string `koanf:"audience"`
+	WilsonCommandID string `koanf:"wilson_command_id"`
+	BrokenCommandID string `koanf:"broken_command_id"`
+	StatsCommandID  string `koanf:"stats_command_id"`
 }
 
 type DiscordWebhookConfig struct {
+	Enabled     bool      `koanf:"enabled"`
+	WebhookURL  string    `koanf:"webhook_url"`
+	DryRun      bool      `koanf:"dry_run"`
+	TemplateDir string    `koanf:"template_dir"`
+	AssetDir    string    `koanf:"asset_dir"`
+	LimitMode   LimitMode `koanf:"limit_mode"`
+
+	Threads DiscordThreadsConfig `koanf:"threads"`
+}
+
+// DiscordThreadsConfig picks the thread of every kind of card, the channel
+// of the webhook when unset. The forum posts created are kept in StateFile,
+// or in memory when empty
+type DiscordThreadsConfig struct {
+	StateFile string `koanf:"state_file"`
+
+	Message DiscordThreadConfig `koanf:"message"`
+	Broken  DiscordThreadConfig `koanf:"broken"`
+	Digest  DiscordThreadConfig `koanf:"digest"`
+	Alert   DiscordThreadConfig `koanf:"alert"`
+}
+
+// DiscordThreadConfig posts into an existing thread with ThreadID, or into
+// a forum post named ThreadName, created on the first card. WebhookURL posts
+// the kind into another channel than the one of the default webhook
+type DiscordThreadConfig struct {
 	WebhookURL string `koanf:"webhook_url"`
+	ThreadID   string `koanf:"thread_id"`
+	ThreadName string `koanf:"thread_name"`
+}
+
+type DiscordInteractionsConfig struct {
+	Enabled   bool   `koanf:"enabled"`
+	PublicKey string `koanf:"public_key"`
+}
+
+type SlackConfig struct {
+	Enabled       bool   `koanf:"enabled"`
+	SigningSecret string `koanf:"signing_secret"`
+	BotToken      string `koanf:"bot_token"`
+	APIURL        string `koanf:"api_url"`
+}
+
+type CIConfig struct {
+	Enabled      bool   `koanf:"enabled"`
+	GitHubSecret string `koanf:"github_secret"`
+	GitHubToken  string `koanf:"github_token"`
+	GitHubAPIURL string `koanf:"github_api_url"`
+	GitLabToken  string `koanf:"gitlab_token"`
+}
+
+type TracingConfig struct {
+	Enabled     bool    `koanf:"enabled"`
+	Exporter    string  `koanf:"exporter"`
+	Endpoint    string  `koanf:"endpoint"`
+	Insecure    bool    `koanf:"insecure"`
+	ServiceName string  `koanf:"service_name"`
+	SampleRatio float64 `koanf:"sample_ratio"`
+}
+
+type HealthConfig struct {
+	ProbeWebhooks bool          `koanf:"probe_webhooks"`
+	ProbeTTL      time.Duration `koanf:"probe_ttl"`
+}
+
+type ImageConfig struct {
+	Id   string   `koanf:"id"`
+	URL  string   `koanf:"url"`
+	Tags []string `koanf:"tags"`
+}
+
+type TeamMemberConfig struct {
+	Id           string   `koanf:"id"`
+	Name         string   `koanf:"name"`
+	Aliases      []string `koanf:"aliases"`
+	DiscordID    string   `koanf:"discord_id"`
+	GoogleChatID string   `koanf:"google_chat_id"`
+	SlackID      string   `koanf:"slack_id"`
+	Away         bool     `koanf:"away"`
+}
+
+type OutOfOfficeConfig struct {
+	// ICalFile is imported on start and again on SIGHUP and every
+	// ReimportInterval, along with the imports through the API
+	ICalFile         string        `koanf:"ical_file"`
+	ReimportInterval time.Duration `koanf:"reimport_interval"`
 }
 
 type Config struct {
-	HTTPConfig       HTTPConfig       `koanf:"http"`
-	CronConfig       CronConfig       `koanf:"cron"`
-	GoogleChatConfig GoogleChatConfig `koanf:"google_chat"`
-	DiscordWebhookConfig DiscordWebhookConfig `koanf:"discord_webhook"`
+	// DryRun puts every sender in dry run, rendering and logging the
+	// payloads instead of posting them
+	DryRun bool `koanf:"dry_run"`
+
+	HTTPConfig                HTTPConfig                `koanf:"http"`
+	CronConfig                CronConfig                `koanf:"cron"`
+	DigestConfig              DigestConfig              `koanf:"digest"`
+	GoogleChatConfig          GoogleChatConfig          `koanf:"google_chat"`
+	GoogleChatEventsConfig    GoogleChatEventsConfig    `koanf:"google_chat_events"`
+	DiscordWebhookConfig      DiscordWebhookConfig      `koanf:"discord_webhook"`
+	DiscordInteractionsConfig DiscordInteractionsConfig `koanf:"discord_interactions"`
+	SlackConfig               SlackConfig               `koanf:"slack"`
+	CIConfig                  CIConfig                  `koanf:"ci"`
+	TracingConfig             TracingConfig             `koanf:"tracing"`
+	HealthConfig              HealthConfig              `koanf:"health"`
+	Images                    []ImageConfig             `koanf:"images"`
+	Roster                    []TeamMemberConfig        `koanf:"roster"`
+	OutOfOfficeConfig         OutOfOfficeConfig         `koanf:"out_of_office"`
+
+	// Placeholders are the default values of the message variables
+	Placeholders map[string]string `koanf:"placeholders"`
 }
 
 func LoadConfig(ctx context.Context) (*Config, error) {
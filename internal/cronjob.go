@@ -4,25 +4,34 @@ import (
 	"context"
 	"log/slog"
 	"math/rand"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-co-op/gocron/v2"
 )
 
+const messageCronJobName = "message"
+
 // MessageCronJob handles scheduled message sending tasks
 type MessageCronJob struct {
 	messageStorer      MessageStorer
 	googleChatProvider MessageSender
 	scheduler          gocron.Scheduler
+	job                gocron.Job
 	cronString         string
+	imageID            string
+	rotation           *Rotation
 	enabled            bool
+	running            atomic.Bool
 }
 
-// NewMessageCronJob creates a new cron job service for scheduled messages
+// NewMessageCronJob creates a new cron job service for scheduled messages,
+// the rotation picks who each message targets when cfg.Rotation is set
 func NewMessageCronJob(
 	cfg CronConfig,
 	messageStorer MessageStorer,
 	googleChatProvider MessageSender,
+	rotation *Rotation,
 ) (*MessageCronJob, error) {
 	scheduler, err := gocron.NewScheduler()
 	if err != nil {
@@ -30,13 +39,20 @@ func NewMessageCronJob(
 		return nil, err
 	}
 
-	return &MessageCronJob{
+	job := &MessageCronJob{
 		messageStorer:      messageStorer,
 		googleChatProvider: googleChatProvider,
 		enabled:            cfg.Enabled,
 		cronString:         cfg.CronString,
+		imageID:            cfg.ImageID,
 		scheduler:          scheduler,
-	}, nil
+	}
+
+	if cfg.Rotation {
+		job.rotation = rotation
+	}
+
+	return job, nil
 }
 
 // Start begins the cron scheduler
@@ -49,7 +65,11 @@ func (c *MessageCronJob) Start(ctx context.Context) error {
 	job, err := c.scheduler.NewJob(
 		gocron.CronJob(c.cronString, false),
 		gocron.NewTask(func() {
-			c.sendDailyMessage(context.Background())
+			ctx := WithPinnedImage(WithTrigger(context.Background(), TriggerCron), c.imageID)
+			ctx, span := Tracer().Start(ctx, "cron."+messageCronJobName)
+			defer span.End()
+
+			c.sendDailyMessage(ctx)
 		}),
 	)
 	if err != nil {
@@ -57,7 +77,9 @@ func (c *MessageCronJob) Start(ctx context.Context) error {
 		return err
 	}
 
+	c.job = job
 	c.scheduler.Start()
+	c.running.Store(true)
 
 	slog.InfoContext(ctx, "cron scheduler started, message will be sent at", slog.String("cron_string", c.cronString), slog.Any("job_id", job.ID()))
 
@@ -67,6 +89,8 @@ func (c *MessageCronJob) Start(ctx context.Context) error {
 // Stop halts the cron scheduler
 func (c *MessageCronJob) Stop(ctx context.Context) {
 	if c.scheduler != nil {
+		c.running.Store(false)
+
 		err := c.scheduler.Shutdown()
 		if err != nil {
 			slog.ErrorContext(ctx, "failed to stop cron scheduler", slog.Any("error", err))
@@ -83,11 +107,13 @@ func (c *MessageCronJob) sendDailyMessage(ctx context.Context) {
 	messages, err := c.messageStorer.GetAllMessages(ctx)
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to get messages", slog.Any("error", err))
+		observeCronRun(messageCronJobName, err)
 		return
 	}
 
 	if len(messages) == 0 {
 		slog.ErrorContext(ctx, "no messages available for sending")
+		observeCronSkip(messageCronJobName)
 		return
 	}
 
@@ -96,7 +122,21 @@ func (c *MessageCronJob) sendDailyMessage(ctx context.Context) {
 	randomIndex := rand.Intn(len(messages))
 	randomMessage := messages[randomIndex]
 
+	ctx, target := c.target(ctx)
+	ctx, posted := WithPostedMessages(ctx)
+
 	err = c.googleChatProvider.SendMessage(ctx, randomMessage)
+	observeCronRun(messageCronJobName, err)
+
+	// the target was called out once any platform got the message, a platform
+	// failing alone doesn't give them another turn
+	if target != nil && (err == nil || len(posted.IDs()) > 0) {
+		advanceErr := c.rotation.Advance(ctx, *target, time.Now())
+		if advanceErr != nil {
+			slog.ErrorContext(ctx, "failed to advance the rotation", slog.String("member_id", target.Id), slog.Any("error", advanceErr))
+		}
+	}
+
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to send message", slog.Any("error", err))
 		return
@@ -106,3 +146,41 @@ func (c *MessageCronJob) sendDailyMessage(ctx context.Context) {
 		slog.String("message_id", randomMessage.Id),
 		slog.String("message", randomMessage.Message))
 }
+
+// target picks the target of the day when rotating, the message goes out
+// to everyone when nobody is available
+func (c *MessageCronJob) target(ctx context.Context) (context.Context, *TeamMember) {
+	if c.rotation == nil {
+		return ctx, nil
+	}
+
+	member, err := c.rotation.Next(ctx, time.Now())
+	if err != nil {
+		slog.WarnContext(ctx, "failed to pick the target of the day, sending to everyone", slog.Any("error", err))
+		return ctx, nil
+	}
+
+	slog.InfoContext(ctx, "picked the target of the day", slog.String("member_id", member.Id), slog.String("name", member.Name))
+
+	ctx = WithMessageVars(WithMessageTarget(ctx, member.Id), map[string]string{"name": member.Name})
+
+	return ctx, member
+}
+
+// NextRun is when the next daily message goes out
+func (c *MessageCronJob) NextRun() (time.Time, error) {
+	if !c.running.Load() {
+		return time.Time{}, ErrNotRunning
+	}
+
+	return c.job.NextRun()
+}
+
+// CheckHealth implements HealthChecker, a disabled job is healthy
+func (c *MessageCronJob) CheckHealth(ctx context.Context) error {
+	if !c.enabled || c.running.Load() {
+		return nil
+	}
+
+	return ErrNotRunning
+}
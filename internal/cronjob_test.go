@@ -0,0 +1,52 @@
+package internal
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSendDailyMessageAdvancesTheRotation(t *testing.T) {
+	tests := []struct {
+		name     string
+		posted   bool
+		err      error
+		advances bool
+	}{
+		{name: "sent everywhere", posted: true, advances: true},
+		{name: "one platform failed", posted: true, err: assert.AnError, advances: true},
+		{name: "every platform failed", err: assert.AnError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := NewMockMessageStorer(t)
+			mockStore.On("GetAllMessages", mock.Anything).Return([]Message{{Id: "1", Message: "Bom dia {{name}}"}}, nil)
+
+			mockSender := NewMockMessageSender(t)
+			mockSender.On("SendMessage", mock.Anything, mock.Anything).
+				Run(func(args mock.Arguments) {
+					if tt.posted {
+						RecordPostedMessage(args.Get(0).(context.Context), "discord", "123")
+					}
+				}).
+				Return(tt.err)
+
+			rotationStorer := NewInMemoryRotationStorer()
+			rotation := NewRotation(NewInMemoryRosterStorer(TeamMember{Id: "ana", Name: "Ana"}), rotationStorer)
+
+			job, err := NewMessageCronJob(CronConfig{Rotation: true}, mockStore, mockSender, rotation)
+			require.NoError(t, err)
+
+			job.sendDailyMessage(t.Context())
+
+			lastTargeted, err := rotationStorer.GetLastTargeted(t.Context())
+			require.NoError(t, err)
+			_, advanced := lastTargeted["ana"]
+			assert.Equal(t, tt.advances, advanced)
+		})
+	}
+}
@@ -0,0 +1,281 @@
+package internal
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"sort"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-co-op/gocron/v2"
+	"github.com/google/uuid"
+)
+
+type DigestPeriod string
+
+const (
+	DigestPeriodWeekly  DigestPeriod = "weekly"
+	DigestPeriodMonthly DigestPeriod = "monthly"
+)
+
+type TagCount struct {
+	Tag   string `json:"tag"`
+	Count int    `json:"count"`
+}
+
+// Digest is the summary of everything Wilson did in a period
+type Digest struct {
+	Id           string            `json:"id"`
+	Period       DigestPeriod      `json:"period"`
+	From         time.Time         `json:"from"`
+	To           time.Time         `json:"to"`
+	MessagesSent int               `json:"messages_sent"`
+	TopTags      []TagCount        `json:"top_tags"`
+	Breakages    []Breakage        `json:"breakages"`
+	Leader       *LeaderboardEntry `json:"leader"`
+	ImageURL     string            `json:"image_url,omitempty"`
+}
+
+func (d Digest) Title() string {
+	if d.Period == DigestPeriodMonthly {
+		return "Resumo mensal do Wilson"
+	}
+
+	return "Resumo semanal do Wilson"
+}
+
+func (d Digest) Interval() string {
+	return d.From.Format("02/01/2006") + " - " + d.To.Format("02/01/2006")
+}
+
+func (d Digest) TopTagsSummary() string {
+	if len(d.TopTags) == 0 {
+		return "Nenhuma tag"
+	}
+
+	tags := make([]string, 0, len(d.TopTags))
+	for _, t := range d.TopTags {
+		tags = append(tags, fmt.Sprintf("%s (%d)", t.Tag, t.Count))
+	}
+
+	return strings.Join(tags, ", ")
+}
+
+func (d Digest) LeaderSummary() string {
+	if d.Leader == nil {
+		return "Ninguém quebrou nada ainda"
+	}
+
+	return fmt.Sprintf("%s (%d quebras)", d.Leader.Name, d.Leader.Breakages)
+}
+
+// NewDigest compiles the activity of the period ending at now
+func NewDigest(
+	ctx context.Context,
+	period DigestPeriod,
+	now time.Time,
+	topTags int,
+	history HistoryStorer,
+	breakageTracker BreakageTracker,
+) (*Digest, error) {
+	var from time.Time
+	switch period {
+	case DigestPeriodWeekly:
+		from = now.AddDate(0, 0, -7)
+	case DigestPeriodMonthly:
+		from = now.AddDate(0, -1, 0)
+	default:
+		return nil, fmt.Errorf("unknown digest period %q", period)
+	}
+
+	records, err := history.GetSendRecords(ctx, from, now)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get send records", slog.Any("error", err))
+		return nil, err
+	}
+
+	breakages, err := breakageTracker.GetBreakages(ctx, from, now)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get breakages", slog.Any("error", err))
+		return nil, err
+	}
+
+	leaderboard, err := breakageTracker.GetLeaderboard(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to get leaderboard", slog.Any("error", err))
+		return nil, err
+	}
+
+	digest := &Digest{
+		Id:        uuid.NewString(),
+		Period:    period,
+		From:      from,
+		To:        now,
+		Breakages: breakages,
+	}
+
+	tagCounts := make(map[string]int)
+	for _, r := range records {
+		if r.Kind != SendKindMessage {
+			continue
+		}
+
+		digest.MessagesSent++
+		for _, tag := range r.Tags {
+			tagCounts[tag]++
+		}
+	}
+
+	for tag, count := range tagCounts {
+		digest.TopTags = append(digest.TopTags, TagCount{Tag: tag, Count: count})
+	}
+
+	sort.Slice(digest.TopTags, func(i, j int) bool {
+		if digest.TopTags[i].Count != digest.TopTags[j].Count {
+			return digest.TopTags[i].Count > digest.TopTags[j].Count
+		}
+		return digest.TopTags[i].Tag < digest.TopTags[j].Tag
+	})
+
+	if len(digest.TopTags) > topTags {
+		digest.TopTags = digest.TopTags[:topTags]
+	}
+
+	if len(leaderboard) > 0 {
+		digest.Leader = &leaderboard[0]
+	}
+
+	return digest, nil
+}
+
+// DigestCronJob sends the weekly and monthly digests
+type DigestCronJob struct {
+	history           HistoryStorer
+	breakageTracker   BreakageTracker
+	messageSender     MessageSender
+	scheduler         gocron.Scheduler
+	weeklyCronString  string
+	monthlyCronString string
+	topTags           int
+	imageID           string
+	enabled           bool
+	running           atomic.Bool
+}
+
+// NewDigestCronJob creates a new cron job service for the digest reports
+func NewDigestCronJob(
+	cfg DigestConfig,
+	history HistoryStorer,
+	breakageTracker BreakageTracker,
+	messageSender MessageSender,
+) (*DigestCronJob, error) {
+	scheduler, err := gocron.NewScheduler()
+	if err != nil {
+		slog.Error("failed to create digest scheduler", slog.Any("error", err))
+		return nil, err
+	}
+
+	return &DigestCronJob{
+		history:           history,
+		breakageTracker:   breakageTracker,
+		messageSender:     messageSender,
+		scheduler:         scheduler,
+		weeklyCronString:  cfg.WeeklyCronString,
+		monthlyCronString: cfg.MonthlyCronString,
+		topTags:           cfg.TopTags,
+		imageID:           cfg.ImageID,
+		enabled:           cfg.Enabled,
+	}, nil
+}
+
+// Start schedules every digest that has a cron string and begins the scheduler
+func (c *DigestCronJob) Start(ctx context.Context) error {
+	if !c.enabled {
+		slog.InfoContext(ctx, "digest jobs are disabled, not starting scheduler")
+		return nil
+	}
+
+	schedules := map[DigestPeriod]string{
+		DigestPeriodWeekly:  c.weeklyCronString,
+		DigestPeriodMonthly: c.monthlyCronString,
+	}
+
+	for period, cronString := range schedules {
+		if cronString == "" {
+			continue
+		}
+
+		job, err := c.scheduler.NewJob(
+			gocron.CronJob(cronString, false),
+			gocron.NewTask(func() {
+				ctx := WithPinnedImage(WithTrigger(context.Background(), TriggerCron), c.imageID)
+				ctx, span := Tracer().Start(ctx, "cron.digest_"+string(period))
+				defer span.End()
+
+				c.sendDigest(ctx, period)
+			}),
+		)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to schedule digest job", slog.String("period", string(period)), slog.Any("error", err))
+			return err
+		}
+
+		slog.InfoContext(ctx, "digest scheduled", slog.String("period", string(period)), slog.String("cron_string", cronString), slog.Any("job_id", job.ID()))
+	}
+
+	c.scheduler.Start()
+	c.running.Store(true)
+
+	return nil
+}
+
+// Stop halts the digest scheduler
+func (c *DigestCronJob) Stop(ctx context.Context) {
+	if c.scheduler != nil {
+		c.running.Store(false)
+
+		err := c.scheduler.Shutdown()
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to stop digest scheduler", slog.Any("error", err))
+		} else {
+			slog.InfoContext(ctx, "digest scheduler stopped successfully")
+		}
+	}
+}
+
+func (c *DigestCronJob) sendDigest(ctx context.Context, period DigestPeriod) {
+	slog.InfoContext(ctx, "executing digest job", slog.String("period", string(period)))
+
+	job := "digest_" + string(period)
+
+	digest, err := NewDigest(ctx, period, time.Now(), c.topTags, c.history, c.breakageTracker)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to build digest", slog.Any("error", err))
+		observeCronRun(job, err)
+		return
+	}
+
+	err = c.messageSender.SendDigest(ctx, *digest)
+	observeCronRun(job, err)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to send digest", slog.Any("error", err))
+		return
+	}
+
+	slog.InfoContext(ctx, "digest sent successfully",
+		slog.String("digest_id", digest.Id),
+		slog.String("period", string(period)),
+		slog.Int("messages_sent", digest.MessagesSent),
+		slog.Int("breakages", len(digest.Breakages)))
+}
+
+// CheckHealth implements HealthChecker, a disabled job is healthy
+func (c *DigestCronJob) CheckHealth(ctx context.Context) error {
+	if !c.enabled || c.running.Load() {
+		return nil
+	}
+
+	return ErrNotRunning
+}
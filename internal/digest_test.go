@@ -0,0 +1,112 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestNewDigest(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2025, 5, 9, 17, 0, 0, 0, time.UTC)
+
+	history := NewInMemoryHistoryStorer()
+	_ = history.AddSendRecord(ctx, SendRecord{Kind: SendKindMessage, Tags: []string{"tech", "general"}, SentAt: now.AddDate(0, 0, -1)})
+	_ = history.AddSendRecord(ctx, SendRecord{Kind: SendKindMessage, Tags: []string{"tech"}, SentAt: now.AddDate(0, 0, -2)})
+	_ = history.AddSendRecord(ctx, SendRecord{Kind: SendKindBroken, SentAt: now.AddDate(0, 0, -2)})
+	_ = history.AddSendRecord(ctx, SendRecord{Kind: SendKindMessage, Tags: []string{"old"}, SentAt: now.AddDate(0, 0, -10)})
+
+	tracker := NewInMemoryBreakageTracker()
+	_ = tracker.RecordBreakage(ctx, Breakage{Name: "Wilson", Motive: "Subiu sem testar", BrokenAt: now.AddDate(0, 0, -3)})
+	_ = tracker.RecordBreakage(ctx, Breakage{Name: "Wilson", Motive: "Force push", BrokenAt: now.AddDate(0, 0, -20)})
+	_ = tracker.RecordBreakage(ctx, Breakage{Name: "Flemis", Motive: "Esqueceu a migration", BrokenAt: now.AddDate(0, 0, -20)})
+
+	digest, err := NewDigest(ctx, DigestPeriodWeekly, now, 1, history, tracker)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 2, digest.MessagesSent)
+	assert.Equal(t, []TagCount{{Tag: "tech", Count: 2}}, digest.TopTags)
+	assert.Len(t, digest.Breakages, 1)
+	assert.Equal(t, "Wilson", digest.Leader.Name)
+	assert.Equal(t, 2, digest.Leader.Breakages)
+
+	digest, err = NewDigest(ctx, DigestPeriodMonthly, now, 3, history, tracker)
+
+	assert.NoError(t, err)
+	assert.Equal(t, 3, digest.MessagesSent)
+	assert.Len(t, digest.Breakages, 3)
+
+	_, err = NewDigest(ctx, DigestPeriod("daily"), now, 3, history, tracker)
+	assert.Error(t, err)
+}
+
+func TestNewLeaderboard(t *testing.T) {
+	now := time.Now()
+	breakages := []Breakage{
+		{Name: "Flemis", BrokenAt: now.Add(-time.Hour)},
+		{Name: "Wilson", BrokenAt: now.Add(-2 * time.Hour)},
+		{Name: "Pedro", BrokenAt: now},
+		{Name: "Wilson", BrokenAt: now.Add(-3 * time.Hour)},
+	}
+
+	leaderboard := NewLeaderboard(breakages)
+
+	assert.Len(t, leaderboard, 3)
+	assert.Equal(t, "Wilson", leaderboard[0].Name)
+	assert.Equal(t, 2, leaderboard[0].Breakages)
+	assert.Equal(t, "Pedro", leaderboard[1].Name)
+	assert.Equal(t, "Flemis", leaderboard[2].Name)
+}
+
+func TestRecordingMessageSenderRecordsBreakageOnFailure(t *testing.T) {
+	ctx := context.Background()
+	mockSender := NewMockMessageSender(t)
+	mockSender.On("SendBrokenMessage", mock.Anything, mock.Anything).Return(assert.AnError)
+
+	history := NewInMemoryHistoryStorer()
+	tracker := NewInMemoryBreakageTracker()
+	sender := NewRecordingMessageSender(mockSender, history, tracker, nil)
+
+	err := sender.SendBrokenMessage(ctx, BrokenMessage{Name: "Wilson", Motive: "Quebrou a main"})
+	assert.ErrorIs(t, err, assert.AnError)
+
+	breakages, _ := tracker.GetBreakages(ctx, time.Time{}, time.Now().Add(time.Minute))
+	assert.Len(t, breakages, 1)
+
+	records, _ := history.GetSendRecords(ctx, time.Time{}, time.Now().Add(time.Minute))
+	assert.Empty(t, records)
+}
+
+func TestGoogleChatDigestCardIsValidJSON(t *testing.T) {
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	sender, err := NewHardcodedGoogleChatProvider(srv.URL, mustGoogleChatTemplates(t), GoogleChatThreadsConfig{}, LimitModeTruncate)
+	assert.NoError(t, err)
+
+	digest := Digest{
+		Id:           "digest",
+		Period:       DigestPeriodWeekly,
+		MessagesSent: 4,
+		Breakages: []Breakage{
+			{Name: "Wilson", Motive: "Subiu sem testar"},
+			{Name: "Flemis", Motive: "Esqueceu a migration"},
+			{Name: `Pedro "PH"`, Motive: "Rodou o script\nem produção"},
+		},
+	}
+
+	err = sender.SendDigest(context.Background(), digest)
+	assert.NoError(t, err)
+	assert.True(t, json.Valid(body), string(body))
+}
@@ -0,0 +1,157 @@
+package discord
+
+import (
+	"bytes"
+	"cmp"
+	"embed"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"mime/multipart"
+	"net/textproto"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/taldoflemis/wilson-bot/internal"
+)
+
+//go:embed assets
+var embeddedAssets embed.FS
+
+// default images of the cards, embedded along with the other assets so
+// Discord gets them uploaded instead of linked
+const (
+	DefaultMessageImageURL = internal.AssetScheme + "pepe.png"
+	DefaultBrokenImageURL  = internal.AssetScheme + "broken.png"
+)
+
+type assetFS struct {
+	dir      fs.FS
+	fallback fs.FS
+}
+
+// NewAssets returns the images uploaded as attachments, read from dir when
+// present there and from the embedded assets otherwise
+func NewAssets(dir string) fs.FS {
+	fallback, _ := fs.Sub(embeddedAssets, "assets")
+
+	assets := &assetFS{fallback: fallback}
+	if dir != "" {
+		assets.dir = os.DirFS(dir)
+	}
+
+	return assets
+}
+
+func (a *assetFS) Open(name string) (fs.File, error) {
+	if a.dir != nil {
+		file, err := a.dir.Open(name)
+		if err == nil {
+			return file, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	return a.fallback.Open(name)
+}
+
+// cardImage is the image shown in the card of the content, the default one
+// when it has none or names an invalid asset
+func cardImage(content any) string {
+	var imageURL, fallback string
+
+	switch c := content.(type) {
+	case internal.Message:
+		imageURL, fallback = c.ImageURL, DefaultMessageImageURL
+	case internal.BrokenMessage:
+		imageURL, fallback = c.ImageURL, DefaultBrokenImageURL
+	case internal.Digest:
+		imageURL, fallback = c.ImageURL, DefaultMessageImageURL
+	default:
+		return ""
+	}
+
+	if _, ok := internal.AssetName(imageURL); !ok && strings.HasPrefix(imageURL, internal.AssetScheme) {
+		return fallback
+	}
+
+	return cmp.Or(imageURL, fallback)
+}
+
+// embedImageURL points the embed to the uploaded file when the image is an
+// asset
+func embedImageURL(imageURL string) string {
+	if name, ok := internal.AssetName(imageURL); ok {
+		return "attachment://" + path.Base(name)
+	}
+
+	return imageURL
+}
+
+type attachment struct {
+	ID       int    `json:"id"`
+	Filename string `json:"filename"`
+}
+
+// withAttachment lists the asset in the payload attachments, as Discord
+// requires for the files sent along with the message
+func withAttachment(payload []byte, imageURL string) ([]byte, error) {
+	name, ok := internal.AssetName(imageURL)
+	if !ok {
+		return payload, nil
+	}
+
+	var body map[string]json.RawMessage
+	err := json.Unmarshal(payload, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	body["attachments"], err = json.Marshal([]attachment{{ID: 0, Filename: path.Base(name)}})
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(body)
+}
+
+// multipartBody builds the form with the payload and the asset file, returning
+// its content type
+func multipartBody(payload []byte, name string, file []byte) (*bytes.Buffer, string, error) {
+	var buf bytes.Buffer
+	writer := multipart.NewWriter(&buf)
+
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", `form-data; name="payload_json"`)
+	header.Set("Content-Type", "application/json")
+
+	part, err := writer.CreatePart(header)
+	if err != nil {
+		return nil, "", err
+	}
+
+	_, err = part.Write(payload)
+	if err != nil {
+		return nil, "", err
+	}
+
+	part, err = writer.CreateFormFile("files[0]", path.Base(name))
+	if err != nil {
+		return nil, "", err
+	}
+
+	_, err = part.Write(file)
+	if err != nil {
+		return nil, "", err
+	}
+
+	err = writer.Close()
+	if err != nil {
+		return nil, "", err
+	}
+
+	return &buf, writer.FormDataContentType(), nil
+}
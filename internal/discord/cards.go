@@ -0,0 +1,215 @@
+package discord
+
+import (
+	"context"
+	"embed"
+	"log/slog"
+
+	"github.com/taldoflemis/wilson-bot/internal"
+)
+
+const (
+	thankingCardTemplateName = "thanking_card_template.json"
+	brokenCardTemplateName   = "broken_card_template.json"
+	digestCardTemplateName   = "digest_card_template.json"
+	alertCardTemplateName    = "alert_card_template.json"
+)
+
+// Discord rejects the whole post when an embed goes past its limits, the
+// cards listing many items keep them short to stay under the 6000 characters
+// and 25 fields of an embed
+const (
+	titleLimit         = 256
+	fieldValueLimit    = 1024
+	contentLimit       = 2000
+	digestNameLimit    = 50
+	digestMotiveLimit  = 150
+	digestMaxBreakages = 20
+	alertNameLimit     = 80
+	alertSummaryBudget = 2500
+	alertMaxItems      = 10
+)
+
+//go:embed thanking_card_template.json broken_card_template.json digest_card_template.json alert_card_template.json
+var defaultTemplates embed.FS
+
+type templateData struct {
+	Message  string
+	ImageURL string
+}
+
+type brokenTemplateData struct {
+	Name            string
+	Motive          string
+	TimeSinceBroken string
+	DayOfBreakage   string
+	ImageURL        string
+}
+
+type digestTemplateData struct {
+	Title        string
+	Interval     string
+	MessagesSent int
+	TopTags      string
+	Leader       string
+	Breakages    []brokenTemplateData
+	ImageURL     string
+}
+
+type alertItemTemplateData struct {
+	Name     string
+	Severity string
+	Summary  string
+	StartsAt string
+}
+
+type alertTemplateData struct {
+	Title       string
+	Status      string
+	Color       int
+	HexColor    string
+	ExternalURL string
+	OnCall      string
+	Roast       string
+	Alerts      []alertItemTemplateData
+}
+
+// NewTemplates loads the Discord card templates from dir, falling back to
+// the embedded ones for the files missing there
+func NewTemplates(dir string) (*internal.TemplateSet, error) {
+	return internal.NewTemplateSet("discord", dir, defaultTemplates, map[string]any{
+		thankingCardTemplateName: templateData{},
+		brokenCardTemplateName:   brokenTemplateData{},
+		digestCardTemplateName:   digestTemplateData{Breakages: []brokenTemplateData{{}}},
+		alertCardTemplateName:    alertTemplateData{Alerts: []alertItemTemplateData{{}, {}}, Roast: "-"},
+	})
+}
+
+// cardRenderer renders the Discord message payloads, shared by the webhook
+// sender and the interactions handler
+type cardRenderer struct {
+	templates *internal.TemplateSet
+}
+
+func newCardRenderer(templates *internal.TemplateSet) *cardRenderer {
+	return &cardRenderer{
+		templates: templates,
+	}
+}
+
+func (r *cardRenderer) renderMessage(ctx context.Context, message internal.Message, fit *internal.Fitter) ([]byte, error) {
+	ctx, span := internal.Tracer().Start(ctx, "cardRenderer.renderMessage")
+	defer span.End()
+
+	data := templateData{
+		Message:  fit.Fit(message.Message, fieldValueLimit),
+		ImageURL: embedImageURL(cardImage(message)),
+	}
+
+	payload, err := r.templates.Execute(thankingCardTemplateName, data)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to execute template", slog.Any("error", err))
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+func (r *cardRenderer) renderBrokenMessage(ctx context.Context, message internal.BrokenMessage, fit *internal.Fitter) ([]byte, error) {
+	ctx, span := internal.Tracer().Start(ctx, "cardRenderer.renderBrokenMessage")
+	defer span.End()
+
+	data := brokenTemplateData{
+		Name:            internal.TruncateText(message.Name, fieldValueLimit),
+		Motive:          fit.Fit(message.Motive, fieldValueLimit),
+		TimeSinceBroken: message.TimeSinceBroken,
+		DayOfBreakage:   message.DayOfBreakage,
+		ImageURL:        embedImageURL(cardImage(message)),
+	}
+
+	payload, err := r.templates.Execute(brokenCardTemplateName, data)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to execute template", slog.Any("error", err))
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// renderDigest lists the first breakages only, with their texts truncated
+func (r *cardRenderer) renderDigest(ctx context.Context, digest internal.Digest) ([]byte, error) {
+	ctx, span := internal.Tracer().Start(ctx, "cardRenderer.renderDigest")
+	defer span.End()
+
+	data := digestTemplateData{
+		Title:        digest.Title(),
+		Interval:     digest.Interval(),
+		MessagesSent: digest.MessagesSent,
+		TopTags:      digest.TopTagsSummary(),
+		Leader:       digest.LeaderSummary(),
+		ImageURL:     embedImageURL(cardImage(digest)),
+	}
+
+	for _, b := range digest.Breakages[:min(len(digest.Breakages), digestMaxBreakages)] {
+		data.Breakages = append(data.Breakages, brokenTemplateData{
+			Name:          internal.TruncateText(b.Name, digestNameLimit),
+			Motive:        internal.TruncateText(b.Motive, digestMotiveLimit),
+			DayOfBreakage: b.BrokenAt.Format("02/01/2006"),
+		})
+	}
+
+	payload, err := r.templates.Execute(digestCardTemplateName, data)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to execute template", slog.Any("error", err))
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// renderAlert lists the first alerts only, sharing the summary budget
+// between them
+func (r *cardRenderer) renderAlert(ctx context.Context, notification internal.AlertNotification, fit *internal.Fitter) ([]byte, error) {
+	ctx, span := internal.Tracer().Start(ctx, "cardRenderer.renderAlert")
+	defer span.End()
+
+	status := "Disparando"
+	if notification.Status == internal.AlertStatusResolved {
+		status = "Resolvido"
+	}
+
+	data := alertTemplateData{
+		Title:       internal.TruncateText(notification.Title(), titleLimit),
+		Status:      status,
+		Color:       notification.Color(),
+		HexColor:    notification.HexColor(),
+		ExternalURL: notification.ExternalURL,
+		OnCall:      notification.OnCall,
+		Roast:       fit.Fit(notification.Roast, fieldValueLimit),
+	}
+
+	alerts := notification.Alerts[:min(len(notification.Alerts), alertMaxItems)]
+	summaryLimit := alertSummaryBudget / max(len(alerts), 1)
+
+	for _, a := range alerts {
+		summary := a.Summary
+		if summary == "" {
+			summary = a.Description
+		}
+
+		data.Alerts = append(data.Alerts, alertItemTemplateData{
+			Name:     internal.TruncateText(a.Name, alertNameLimit),
+			Severity: a.Severity,
+			Summary:  internal.TruncateText(summary, summaryLimit),
+			StartsAt: a.StartsAt.Format("02/01/2006 15:04"),
+		})
+	}
+
+	payload, err := r.templates.Execute(alertCardTemplateName, data)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to execute template", slog.Any("error", err))
+		return nil, err
+	}
+
+	return payload, nil
+}
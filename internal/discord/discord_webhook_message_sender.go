@@ -2,132 +2,418 @@ package discord
 
 import (
 	"bytes"
+	"cmp"
 	"context"
+	"encoding/json"
 	"errors"
-	"html/template"
+	"fmt"
+	"io"
+	"io/fs"
 	"log/slog"
 	"net/http"
-
-	_ "embed"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
 
 	"github.com/taldoflemis/wilson-bot/internal"
 )
 
-//go:embed thanking_card_template.json
-var cardTemplate []byte
-
-//go:embed broken_card_template.json
-var brokenCardTemplate []byte
+// platform names the Discord senders in the send history
+const platform = "discord"
 
 type DiscordWebhookMessageSender struct {
-	webhookURL         string
-	pearlCardTemplate  *template.Template
-	brokenCardTemplate *template.Template
-	httpClient         *http.Client
-}
-
-type templateData struct {
-	Message string
-}
-
-type brokenTemplateData struct {
-	Name            string
-	Motive          string
-	TimeSinceBroken string
-	DayOfBreakage   string
+	webhookURL string
+	cards      *cardRenderer
+	assets     fs.FS
+	threads    *threads
+	limitMode  internal.LimitMode
+	httpClient *http.Client
 }
 
 var (
 	_ internal.MessageSender = (*DiscordWebhookMessageSender)(nil)
+	_ internal.Renderer      = (*DiscordWebhookMessageSender)(nil)
+	_ internal.MessageEditor = (*DiscordWebhookMessageSender)(nil)
 )
 
-func NewDiscordWebhookMessageSender(webhookURL string) (*DiscordWebhookMessageSender, error) {
-	tmpl, err := template.New("email_body.tmpl.xml").Parse(string(cardTemplate))
+func NewDiscordWebhookMessageSender(
+	webhookURL string,
+	templates *internal.TemplateSet,
+	assets fs.FS,
+	threadsCfg internal.DiscordThreadsConfig,
+	limitMode internal.LimitMode,
+) (*DiscordWebhookMessageSender, error) {
+	threads, err := newThreads(threadsCfg)
 	if err != nil {
 		return nil, err
 	}
 
-	brokenTmpl, err := template.New("broken.tmpl.json").Parse(string(brokenCardTemplate))
+	err = limitMode.Validate()
 	if err != nil {
-		slog.Error("failed to parse broken card template", slog.Any("error", err))
 		return nil, err
 	}
 
 	return &DiscordWebhookMessageSender{
-		webhookURL:         webhookURL,
-		pearlCardTemplate:  tmpl,
-		brokenCardTemplate: brokenTmpl,
-		httpClient:         &http.Client{},
+		webhookURL: webhookURL,
+		cards:      newCardRenderer(templates),
+		assets:     assets,
+		threads:    threads,
+		limitMode:  limitMode,
+		httpClient: internal.NewTracedHTTPClient(0),
 	}, nil
 }
 
 func (h *DiscordWebhookMessageSender) SendMessage(ctx context.Context, message internal.Message) error {
-	data := templateData{
-		Message: message.Message,
+	return h.send(ctx, message, h.threads.resolve(internal.SendKindMessage, "", time.Now()))
+}
+
+// SendBrokenMessage implements GoogleChatProvider.
+func (h *DiscordWebhookMessageSender) SendBrokenMessage(ctx context.Context, message internal.BrokenMessage) error {
+	return h.send(ctx, message, h.threads.resolve(internal.SendKindBroken, message.Name, time.Now()))
+}
+
+// SendDigest implements internal.MessageSender.
+func (h *DiscordWebhookMessageSender) SendDigest(ctx context.Context, digest internal.Digest) error {
+	return h.send(ctx, digest, h.threads.resolve(internal.SendKindDigest, "", time.Now()))
+}
+
+// SendAlert implements internal.MessageSender.
+func (h *DiscordWebhookMessageSender) SendAlert(ctx context.Context, notification internal.AlertNotification) error {
+	return h.send(ctx, notification, h.threads.resolve(internal.SendKindAlert, "", time.Now()))
+}
+
+// Render implements internal.Renderer, it returns the exact body the webhook
+// receives for the content, the payload_json part when an asset is attached.
+// The follow ups of a split text are left out
+func (h *DiscordWebhookMessageSender) Render(ctx context.Context, content any) ([]byte, error) {
+	return h.render(ctx, content, internal.NewFitter(h.limitMode))
+}
+
+func (h *DiscordWebhookMessageSender) render(ctx context.Context, content any, fit *internal.Fitter) ([]byte, error) {
+	var (
+		payload []byte
+		err     error
+	)
+
+	switch c := content.(type) {
+	case internal.Message:
+		payload, err = h.cards.renderMessage(ctx, c, fit)
+	case internal.BrokenMessage:
+		payload, err = h.cards.renderBrokenMessage(ctx, c, fit)
+	case internal.Digest:
+		payload, err = h.cards.renderDigest(ctx, c)
+	case internal.AlertNotification:
+		payload, err = h.cards.renderAlert(ctx, c, fit)
+	default:
+		return nil, internal.ErrUnsupportedContent
 	}
 
-	var buf bytes.Buffer
+	if err != nil {
+		return nil, err
+	}
 
-	err := h.pearlCardTemplate.Execute(&buf, data)
+	payload, err = withMentions(payload, internal.Mentions(ctx))
+	if err != nil {
+		return nil, err
+	}
+
+	return withAttachment(payload, cardImage(content))
+}
+
+// send posts the card followed by the texts that didn't fit in it, in the
+// same thread
+func (h *DiscordWebhookMessageSender) send(ctx context.Context, content any, th thread) error {
+	fit := internal.NewFitter(h.limitMode)
+
+	payload, err := h.render(ctx, content, fit)
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to execute template", slog.Any("error", err))
 		return err
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.webhookURL, &buf)
+	landed, err := h.post(ctx, payload, cardImage(content), th)
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to create request", slog.Any("error", err))
 		return err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	for _, text := range fit.FollowUps(contentLimit) {
+		err = h.postFollowUp(ctx, text, landed)
+		if err != nil {
+			return err
+		}
+	}
 
-	resp, err := h.httpClient.Do(req)
+	return nil
+}
+
+// CheckHealth implements internal.HealthChecker, checking the webhooks of the
+// kinds of card as well
+func (h *DiscordWebhookMessageSender) CheckHealth(ctx context.Context) error {
+	err := internal.ValidateWebhookURL(h.webhookURL)
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to send request", slog.Any("error", err))
 		return err
 	}
 
-	if resp.StatusCode != http.StatusNoContent {
-		slog.ErrorContext(ctx, "unexpected status code", slog.Any("status_code", resp.StatusCode))
-		return errors.New("unexpected status code")
+	for _, kind := range []string{internal.SendKindMessage, internal.SendKindBroken, internal.SendKindDigest, internal.SendKindAlert} {
+		if webhookURL := h.threads.webhookURL(kind); webhookURL != "" {
+			err = internal.ValidateWebhookURL(webhookURL)
+			if err != nil {
+				return fmt.Errorf("%s webhook: %w", kind, err)
+			}
+		}
 	}
 
 	return nil
 }
 
-// SendBrokenMessage implements GoogleChatProvider.
-func (h *DiscordWebhookMessageSender) SendBrokenMessage(ctx context.Context, message internal.BrokenMessage) error {
-	data := brokenTemplateData{
-		Name:            message.Name,
-		Motive:          message.Motive,
-		TimeSinceBroken: message.TimeSinceBroken,
-		DayOfBreakage:   message.DayOfBreakage,
+// webhookFor is the webhook of the kind of card, the default one when the
+// kind has none
+func (h *DiscordWebhookMessageSender) webhookFor(kind string) string {
+	return cmp.Or(h.threads.webhookURL(kind), h.webhookURL)
+}
+
+// EditMessage implements internal.MessageEditor, replacing the card posted
+// by the webhook. There are no follow ups to edit, the text is truncated
+func (h *DiscordWebhookMessageSender) EditMessage(ctx context.Context, messageID string, message internal.Message) error {
+	payload, err := h.render(ctx, message, internal.NewFitter(internal.LimitModeTruncate))
+	if err != nil {
+		return err
 	}
 
-	var buf bytes.Buffer
+	th, messageID := parsePostedID(messageID)
 
-	err := h.brokenCardTemplate.Execute(&buf, data)
+	resp, err := h.do(ctx, http.MethodPatch, h.webhookFor(th.webhook), messageID, threadQuery(th.id), payload, cardImage(message))
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to execute template", slog.Any("error", err))
 		return err
 	}
+	defer resp.Body.Close()
+
+	return checkStatus(ctx, resp, http.StatusOK)
+}
+
+// DeleteMessage implements internal.MessageEditor, removing the card posted
+// by the webhook
+func (h *DiscordWebhookMessageSender) DeleteMessage(ctx context.Context, messageID string) error {
+	th, messageID := parsePostedID(messageID)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.webhookURL, &buf)
+	resp, err := h.do(ctx, http.MethodDelete, h.webhookFor(th.webhook), messageID, threadQuery(th.id), nil, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	return checkStatus(ctx, resp, http.StatusNoContent)
+}
+
+type postedMessage struct {
+	ID        string `json:"id"`
+	ChannelID string `json:"channel_id"`
+}
+
+// post waits for Discord to create the message, recording its id so it can
+// be edited or deleted later. It returns the thread the message landed in
+func (h *DiscordWebhookMessageSender) post(ctx context.Context, payload []byte, imageURL string, th thread) (thread, error) {
+	landed := thread{id: th.id, webhook: th.webhook}
+
+	payload, err := withThreadName(payload, th.name)
+	if err != nil {
+		return landed, err
+	}
+
+	query := threadQuery(th.id)
+	query.Set("wait", "true")
+
+	resp, err := h.do(ctx, http.MethodPost, h.webhookFor(th.webhook), "", query, payload, imageURL)
+	if err != nil {
+		return landed, err
+	}
+	defer resp.Body.Close()
+
+	err = checkStatus(ctx, resp, http.StatusOK)
+	if err != nil {
+		return landed, err
+	}
+
+	var posted postedMessage
+	err = json.NewDecoder(resp.Body).Decode(&posted)
+	if err != nil || posted.ID == "" {
+		// the message is already out, it just can't be changed later
+		slog.WarnContext(ctx, "failed to read posted message id", slog.Any("error", err))
+		return landed, nil
+	}
+
+	if th.name != "" {
+		err = h.threads.remember(th, posted.ChannelID)
+		if err != nil {
+			slog.WarnContext(ctx, "failed to save the created thread", slog.String("thread_name", th.name), slog.Any("error", err))
+		}
+		landed.id = posted.ChannelID
+	}
+
+	internal.RecordPostedMessage(ctx, platform, postedID(landed, posted.ID))
+
+	return landed, nil
+}
+
+// postFollowUp sends the text as a plain message into the thread of the card,
+// recording it so it is retracted along with the card
+func (h *DiscordWebhookMessageSender) postFollowUp(ctx context.Context, text string, th thread) error {
+	payload, err := json.Marshal(map[string]string{"content": text})
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to create request", slog.Any("error", err))
 		return err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	query := threadQuery(th.id)
+	query.Set("wait", "true")
+
+	resp, err := h.do(ctx, http.MethodPost, h.webhookFor(th.webhook), "", query, payload, "")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	err = checkStatus(ctx, resp, http.StatusOK)
+	if err != nil {
+		return err
+	}
+
+	var posted postedMessage
+	err = json.NewDecoder(resp.Body).Decode(&posted)
+	if err != nil || posted.ID == "" {
+		slog.WarnContext(ctx, "failed to read posted message id", slog.Any("error", err))
+		return nil
+	}
+
+	internal.RecordPostedMessage(ctx, platform, postedID(th, posted.ID))
+
+	return nil
+}
+
+func threadQuery(threadID string) url.Values {
+	query := url.Values{}
+	if threadID != "" {
+		query.Set("thread_id", threadID)
+	}
+
+	return query
+}
+
+// do sends the payload to the webhook, or to one of its messages when
+// messageID is set. The payload goes as JSON, or as multipart along with the
+// file when the image is an asset
+func (h *DiscordWebhookMessageSender) do(
+	ctx context.Context,
+	method string,
+	webhookURL string,
+	messageID string,
+	query url.Values,
+	payload []byte,
+	imageURL string,
+) (*http.Response, error) {
+	endpoint, err := endpoint(webhookURL, messageID, query)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to build webhook url", slog.Any("error", err))
+		return nil, err
+	}
+
+	var body io.Reader
+	contentType := "application/json"
+
+	if payload != nil {
+		body = bytes.NewReader(payload)
+	}
+
+	if name, ok := internal.AssetName(imageURL); ok {
+		file, err := fs.ReadFile(h.assets, name)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to read asset", slog.String("asset", name), slog.Any("error", err))
+			return nil, err
+		}
+
+		body, contentType, err = multipartBody(payload, name, file)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to build multipart body", slog.Any("error", err))
+			return nil, err
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, body)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to create request", slog.Any("error", err))
+		return nil, err
+	}
+
+	if body != nil {
+		req.Header.Set("Content-Type", contentType)
+	}
 
 	resp, err := h.httpClient.Do(req)
 	if err != nil {
+		err = redactURLError(err)
 		slog.ErrorContext(ctx, "failed to send request", slog.Any("error", err))
+		return nil, err
+	}
+
+	return resp, nil
+}
+
+// endpoint builds the url of the webhook, with the message path and the query
+// of the call
+func endpoint(webhookURL string, messageID string, query url.Values) (string, error) {
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return "", errors.New("invalid webhook url")
+	}
+
+	if messageID != "" {
+		u = u.JoinPath("messages", messageID)
+	}
+
+	values := u.Query()
+	for key, value := range query {
+		values[key] = value
+	}
+	u.RawQuery = values.Encode()
+
+	return u.String(), nil
+}
+
+// redactURLError keeps the webhook token out of the errors of the client,
+// which carry the whole url, as the token grants posting to the channel
+func redactURLError(err error) error {
+	var urlErr *url.Error
+	if !errors.As(err, &urlErr) {
 		return err
 	}
 
-	if resp.StatusCode != http.StatusNoContent {
+	urlErr.URL = redactWebhookURL(urlErr.URL)
+
+	return err
+}
+
+// redactWebhookURL replaces the token following the webhook id in
+// /api/webhooks/<id>/<token>
+func redactWebhookURL(webhookURL string) string {
+	u, err := url.Parse(webhookURL)
+	if err != nil {
+		return "<invalid webhook url>"
+	}
+
+	segments := strings.Split(u.Path, "/")
+	i := slices.Index(segments, "webhooks")
+	if i < 0 || i+2 >= len(segments) {
+		return u.Redacted()
+	}
+
+	segments[i+2] = "REDACTED"
+	u.Path = strings.Join(segments, "/")
+	u.RawPath = ""
+
+	return u.Redacted()
+}
+
+func checkStatus(ctx context.Context, resp *http.Response, expected int) error {
+	if resp.StatusCode != expected {
 		slog.ErrorContext(ctx, "unexpected status code", slog.Any("status_code", resp.StatusCode))
 		return errors.New("unexpected status code")
 	}
@@ -0,0 +1,478 @@
+package discord
+
+import (
+	"encoding/json"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/taldoflemis/wilson-bot/internal"
+)
+
+type embedPayload struct {
+	Embeds []struct {
+		Fields []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"fields"`
+	} `json:"embeds"`
+}
+
+// requestPayload is the JSON payload of the request, which goes along with
+// the card image when it is an asset
+func requestPayload(t *testing.T, r *http.Request) []byte {
+	t.Helper()
+
+	if !strings.HasPrefix(r.Header.Get("Content-Type"), "multipart/") {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		return body
+	}
+
+	require.NoError(t, r.ParseMultipartForm(1<<20))
+	return []byte(r.FormValue("payload_json"))
+}
+
+func TestDiscordSenderRoundTripsEveryMessage(t *testing.T) {
+	var delivered []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered = requestPayload(t, r)
+		w.Write([]byte(`{"id":"1"}`))
+	}))
+	defer srv.Close()
+
+	sender, err := NewDiscordWebhookMessageSender(srv.URL, mustTemplates(t), NewAssets(""), internal.DiscordThreadsConfig{}, internal.LimitModeTruncate)
+	require.NoError(t, err)
+
+	messages, err := internal.GetMessages(t.Context(), internal.RawMessages)
+	require.NoError(t, err)
+
+	messages = append(messages, internal.Message{Id: "quotes", Message: "Ele disse \"não\"\ne saiu"})
+
+	for _, message := range messages {
+		require.NoError(t, sender.SendMessage(t.Context(), message), message.Id)
+
+		var payload embedPayload
+		require.NoError(t, json.Unmarshal(delivered, &payload), message.Id)
+		require.Len(t, payload.Embeds, 1, message.Id)
+		assert.Equal(t, message.Message, payload.Embeds[0].Fields[0].Value, message.Id)
+	}
+}
+
+func TestDiscordSenderKeepsAccentsInBrokenMessage(t *testing.T) {
+	sender, err := NewDiscordWebhookMessageSender("", mustTemplates(t), NewAssets(""), internal.DiscordThreadsConfig{}, internal.LimitModeTruncate)
+	require.NoError(t, err)
+
+	payload, err := sender.Render(t.Context(), internal.BrokenMessage{Name: "D'Ávila", Motive: "Deu \"push --force\""})
+	require.NoError(t, err)
+
+	var decoded embedPayload
+	require.NoError(t, json.Unmarshal(payload, &decoded))
+	assert.Equal(t, "D'Ávila", decoded.Embeds[0].Fields[0].Value)
+	assert.Equal(t, "Deu \"push --force\"", decoded.Embeds[0].Fields[1].Value)
+}
+
+func TestDiscordSenderMentionsTeamMembers(t *testing.T) {
+	sender, err := NewDiscordWebhookMessageSender("", mustTemplates(t), NewAssets(""), internal.DiscordThreadsConfig{}, internal.LimitModeTruncate)
+	require.NoError(t, err)
+
+	ctx := internal.WithMentions(t.Context(), internal.TeamMember{Name: "Ana", DiscordID: "123"}, internal.TeamMember{Name: "Bruno"})
+
+	payload, err := sender.Render(ctx, internal.BrokenMessage{Name: "Ana", Motive: "push --force"})
+	require.NoError(t, err)
+
+	var decoded struct {
+		Content         string `json:"content"`
+		AllowedMentions struct {
+			Users []string `json:"users"`
+		} `json:"allowed_mentions"`
+	}
+	require.NoError(t, json.Unmarshal(payload, &decoded))
+	assert.Equal(t, "<@123>", decoded.Content)
+	assert.Equal(t, []string{"123"}, decoded.AllowedMentions.Users)
+
+	payload, err = sender.Render(t.Context(), internal.BrokenMessage{Name: "Ana", Motive: "push --force"})
+	require.NoError(t, err)
+	assert.NotContains(t, string(payload), "allowed_mentions")
+}
+
+func mustTemplates(t *testing.T) *internal.TemplateSet {
+	t.Helper()
+
+	templates, err := NewTemplates("")
+	require.NoError(t, err)
+
+	return templates
+}
+
+func TestDiscordSenderUploadsAssetImage(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "pepe.png"), []byte("png"), 0o600))
+
+	var (
+		payload  []byte
+		uploaded []byte
+		filename string
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+
+		payload = []byte(r.FormValue("payload_json"))
+
+		file, header, err := r.FormFile("files[0]")
+		require.NoError(t, err)
+		defer file.Close()
+
+		filename = header.Filename
+		uploaded, _ = io.ReadAll(file)
+
+		w.Write([]byte(`{"id":"1"}`))
+	}))
+	defer srv.Close()
+
+	sender, err := NewDiscordWebhookMessageSender(srv.URL, mustTemplates(t), NewAssets(dir), internal.DiscordThreadsConfig{}, internal.LimitModeTruncate)
+	require.NoError(t, err)
+
+	err = sender.SendMessage(t.Context(), internal.Message{Message: "Obrigado", ImageURL: "asset://pepe.png"})
+	require.NoError(t, err)
+
+	assert.Equal(t, "pepe.png", filename)
+	assert.Equal(t, []byte("png"), uploaded)
+
+	var decoded struct {
+		Embeds []struct {
+			Image struct {
+				URL string `json:"url"`
+			} `json:"image"`
+		} `json:"embeds"`
+		Attachments []attachment `json:"attachments"`
+	}
+	require.NoError(t, json.Unmarshal(payload, &decoded))
+	assert.Equal(t, "attachment://pepe.png", decoded.Embeds[0].Image.URL)
+	assert.Equal(t, []attachment{{ID: 0, Filename: "pepe.png"}}, decoded.Attachments)
+}
+
+func TestDiscordSenderUploadsDefaultImage(t *testing.T) {
+	var filename string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		require.NoError(t, r.ParseMultipartForm(1<<20))
+
+		_, header, err := r.FormFile("files[0]")
+		require.NoError(t, err)
+		filename = header.Filename
+
+		w.Write([]byte(`{"id":"1"}`))
+	}))
+	defer srv.Close()
+
+	sender, err := NewDiscordWebhookMessageSender(srv.URL, mustTemplates(t), NewAssets(""), internal.DiscordThreadsConfig{}, internal.LimitModeTruncate)
+	require.NoError(t, err)
+
+	require.NoError(t, sender.SendBrokenMessage(t.Context(), internal.BrokenMessage{Name: "Wilson", Motive: "Subiu sem testar"}))
+	assert.Equal(t, "broken.png", filename)
+
+	// an invalid asset name falls back to the default as well
+	require.NoError(t, sender.SendMessage(t.Context(), internal.Message{Message: "Obrigado", ImageURL: "asset://../pepe.png"}))
+	assert.Equal(t, "pepe.png", filename)
+}
+
+func TestDiscordSenderFailsOnMissingAsset(t *testing.T) {
+	sender, err := NewDiscordWebhookMessageSender("http://127.0.0.1:1", mustTemplates(t), NewAssets(t.TempDir()), internal.DiscordThreadsConfig{}, internal.LimitModeTruncate)
+	require.NoError(t, err)
+
+	err = sender.SendMessage(t.Context(), internal.Message{Message: "Obrigado", ImageURL: "asset://missing.png"})
+	assert.ErrorIs(t, err, fs.ErrNotExist)
+}
+
+func TestDiscordSenderKeepsTheTokenOutOfErrors(t *testing.T) {
+	sender, err := NewDiscordWebhookMessageSender("http://127.0.0.1:1/api/webhooks/1/secret-token", mustTemplates(t), NewAssets(""), internal.DiscordThreadsConfig{}, internal.LimitModeTruncate)
+	require.NoError(t, err)
+
+	err = sender.SendMessage(t.Context(), internal.Message{Message: "Obrigado"})
+	require.Error(t, err)
+	assert.NotContains(t, err.Error(), "secret-token")
+	assert.Contains(t, err.Error(), "/api/webhooks/1/REDACTED")
+}
+
+func TestDiscordSenderMessageLifecycle(t *testing.T) {
+	type call struct {
+		method string
+		path   string
+		query  string
+	}
+
+	var calls []call
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls = append(calls, call{method: r.Method, path: r.URL.Path, query: r.URL.RawQuery})
+
+		switch r.Method {
+		case http.MethodPost, http.MethodPatch:
+			w.Write([]byte(`{"id":"123"}`))
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	sender, err := NewDiscordWebhookMessageSender(srv.URL+"/api/webhooks/1/token", mustTemplates(t), NewAssets(""), internal.DiscordThreadsConfig{}, internal.LimitModeTruncate)
+	require.NoError(t, err)
+
+	ctx, posted := internal.WithPostedMessages(t.Context())
+	require.NoError(t, sender.SendMessage(ctx, internal.Message{Message: "Obrigado"}))
+	assert.Equal(t, map[string][]string{"discord": {"123"}}, posted.IDs())
+
+	require.NoError(t, sender.EditMessage(t.Context(), "123", internal.Message{Message: "Desculpa"}))
+	require.NoError(t, sender.DeleteMessage(t.Context(), "123"))
+
+	assert.Equal(t, []call{
+		{method: http.MethodPost, path: "/api/webhooks/1/token", query: "wait=true"},
+		{method: http.MethodPatch, path: "/api/webhooks/1/token/messages/123"},
+		{method: http.MethodDelete, path: "/api/webhooks/1/token/messages/123"},
+	}, calls)
+}
+
+func TestDiscordSenderThreads(t *testing.T) {
+	type call struct {
+		method     string
+		query      string
+		threadName string
+	}
+
+	var calls []call
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			ThreadName string `json:"thread_name"`
+		}
+		_ = json.Unmarshal(requestPayload(t, r), &body)
+
+		calls = append(calls, call{method: r.Method, query: r.URL.RawQuery, threadName: body.ThreadName})
+
+		switch r.Method {
+		case http.MethodPost:
+			w.Write([]byte(`{"id":"1","channel_id":"forum-post"}`))
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	sender, err := NewDiscordWebhookMessageSender(srv.URL, mustTemplates(t), NewAssets(""), internal.DiscordThreadsConfig{
+		Message: internal.DiscordThreadConfig{ThreadID: "daily"},
+		Broken:  internal.DiscordThreadConfig{ThreadName: "Hall of shame - {name}"},
+	}, internal.LimitModeTruncate)
+	require.NoError(t, err)
+
+	require.NoError(t, sender.SendMessage(t.Context(), internal.Message{Message: "Obrigado"}))
+	require.NoError(t, sender.SendBrokenMessage(t.Context(), internal.BrokenMessage{Name: "Wilson"}))
+
+	ctx, posted := internal.WithPostedMessages(t.Context())
+	require.NoError(t, sender.SendBrokenMessage(ctx, internal.BrokenMessage{Name: "Wilson"}))
+	assert.Equal(t, []string{"forum-post/1"}, posted.IDs()["discord"])
+
+	require.NoError(t, sender.DeleteMessage(t.Context(), posted.IDs()["discord"][0]))
+
+	assert.Equal(t, []call{
+		{method: http.MethodPost, query: "thread_id=daily&wait=true"},
+		{method: http.MethodPost, query: "wait=true", threadName: "Hall of shame - Wilson"},
+		{method: http.MethodPost, query: "thread_id=forum-post&wait=true"},
+		{method: http.MethodDelete, query: "thread_id=forum-post"},
+	}, calls)
+}
+
+func TestDiscordSenderThreadsOutliveRestarts(t *testing.T) {
+	var threadNames []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body struct {
+			ThreadName string `json:"thread_name"`
+		}
+		_ = json.Unmarshal(requestPayload(t, r), &body)
+		threadNames = append(threadNames, body.ThreadName)
+
+		w.Write([]byte(`{"id":"1","channel_id":"forum-post"}`))
+	}))
+	defer srv.Close()
+
+	cfg := internal.DiscordThreadsConfig{
+		StateFile: filepath.Join(t.TempDir(), "threads.json"),
+		Broken:    internal.DiscordThreadConfig{ThreadName: "Hall of shame - {name}"},
+	}
+
+	for range 2 {
+		sender, err := NewDiscordWebhookMessageSender(srv.URL, mustTemplates(t), NewAssets(""), cfg, internal.LimitModeTruncate)
+		require.NoError(t, err)
+		require.NoError(t, sender.SendBrokenMessage(t.Context(), internal.BrokenMessage{Name: "Wilson"}))
+	}
+
+	assert.Equal(t, []string{"Hall of shame - Wilson", ""}, threadNames)
+}
+
+func TestDiscordSenderWebhookPerKind(t *testing.T) {
+	var paths []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.Method+" "+r.URL.Path)
+
+		switch r.Method {
+		case http.MethodPost:
+			w.Write([]byte(`{"id":"1"}`))
+		case http.MethodDelete:
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer srv.Close()
+
+	sender, err := NewDiscordWebhookMessageSender(srv.URL+"/default", mustTemplates(t), NewAssets(""), internal.DiscordThreadsConfig{
+		Broken: internal.DiscordThreadConfig{WebhookURL: srv.URL + "/broken"},
+	}, internal.LimitModeTruncate)
+	require.NoError(t, err)
+
+	ctx, posted := internal.WithPostedMessages(t.Context())
+	require.NoError(t, sender.SendMessage(ctx, internal.Message{Message: "Obrigado"}))
+	require.NoError(t, sender.SendBrokenMessage(ctx, internal.BrokenMessage{Name: "Wilson"}))
+
+	ids := posted.IDs()[platform]
+	assert.Equal(t, []string{"1", "broken:1"}, ids)
+
+	for _, id := range ids {
+		require.NoError(t, sender.DeleteMessage(t.Context(), id))
+	}
+
+	assert.Equal(t, []string{
+		"POST /default",
+		"POST /broken",
+		"DELETE /default/messages/1",
+		"DELETE /broken/messages/1",
+	}, paths)
+}
+
+func TestThreadNames(t *testing.T) {
+	th, err := newThreads(internal.DiscordThreadsConfig{
+		Broken: internal.DiscordThreadConfig{ThreadName: "Hall of shame - {name}"},
+	})
+	require.NoError(t, err)
+
+	now := time.Now()
+
+	assert.Equal(t, "Hall of shame - Desconhecido", th.resolve(internal.SendKindBroken, "  ", now).name)
+
+	name := th.resolve(internal.SendKindBroken, strings.Repeat("Wilson ", 30), now).name
+	assert.Equal(t, threadNameLimit, utf8.RuneCountInString(name))
+	assert.True(t, strings.HasSuffix(name, "…"))
+}
+
+func TestDiscordSenderRejectsAmbiguousThread(t *testing.T) {
+	_, err := NewDiscordWebhookMessageSender("", mustTemplates(t), NewAssets(""), internal.DiscordThreadsConfig{
+		Alert: internal.DiscordThreadConfig{ThreadID: "1", ThreadName: "alertas"},
+	}, internal.LimitModeTruncate)
+	assert.ErrorIs(t, err, ErrInvalidThread)
+}
+
+func TestDiscordSenderLimits(t *testing.T) {
+	type embed struct {
+		Title  string `json:"title"`
+		Fields []struct {
+			Name  string `json:"name"`
+			Value string `json:"value"`
+		} `json:"fields"`
+	}
+
+	var delivered []map[string]json.RawMessage
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]json.RawMessage
+		require.NoError(t, json.Unmarshal(requestPayload(t, r), &body))
+		delivered = append(delivered, body)
+
+		if r.URL.Query().Get("wait") == "true" {
+			w.Write([]byte(`{"id":"1"}`))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer srv.Close()
+
+	motive := strings.Repeat("Deu push --force na main. ", 200)
+
+	t.Run("truncate", func(t *testing.T) {
+		delivered = nil
+
+		sender, err := NewDiscordWebhookMessageSender(srv.URL, mustTemplates(t), NewAssets(""), internal.DiscordThreadsConfig{}, internal.LimitModeTruncate)
+		require.NoError(t, err)
+
+		require.NoError(t, sender.SendBrokenMessage(t.Context(), internal.BrokenMessage{Name: "Wilson", Motive: motive}))
+		require.Len(t, delivered, 1)
+
+		var embeds []embed
+		require.NoError(t, json.Unmarshal(delivered[0]["embeds"], &embeds))
+		assert.LessOrEqual(t, utf8.RuneCountInString(embeds[0].Fields[1].Value), fieldValueLimit)
+		assert.True(t, strings.HasSuffix(embeds[0].Fields[1].Value, "…"))
+	})
+
+	t.Run("split", func(t *testing.T) {
+		delivered = nil
+
+		sender, err := NewDiscordWebhookMessageSender(srv.URL, mustTemplates(t), NewAssets(""), internal.DiscordThreadsConfig{}, internal.LimitModeSplit)
+		require.NoError(t, err)
+
+		ctx, posted := internal.WithPostedMessages(t.Context())
+		require.NoError(t, sender.SendBrokenMessage(ctx, internal.BrokenMessage{Name: "Wilson", Motive: motive}))
+		require.Greater(t, len(delivered), 1)
+		assert.Len(t, posted.IDs()[platform], len(delivered))
+
+		var embeds []embed
+		require.NoError(t, json.Unmarshal(delivered[0]["embeds"], &embeds))
+		texts := []string{embeds[0].Fields[1].Value}
+		assert.LessOrEqual(t, utf8.RuneCountInString(texts[0]), fieldValueLimit)
+
+		for _, followUp := range delivered[1:] {
+			var content string
+			require.NoError(t, json.Unmarshal(followUp["content"], &content))
+			assert.LessOrEqual(t, utf8.RuneCountInString(content), contentLimit)
+			texts = append(texts, content)
+		}
+
+		assert.Equal(t, strings.Fields(motive), strings.Fields(strings.Join(texts, " ")))
+	})
+
+	t.Run("digest and alerts fit an embed", func(t *testing.T) {
+		sender, err := NewDiscordWebhookMessageSender("", mustTemplates(t), NewAssets(""), internal.DiscordThreadsConfig{}, internal.LimitModeTruncate)
+		require.NoError(t, err)
+
+		digest := internal.Digest{}
+		notification := internal.AlertNotification{Roast: motive}
+		for range 40 {
+			digest.Breakages = append(digest.Breakages, internal.Breakage{Name: motive, Motive: motive})
+			notification.Alerts = append(notification.Alerts, internal.Alert{Name: motive, Summary: motive})
+		}
+
+		for _, content := range []any{digest, notification} {
+			payload, err := sender.Render(t.Context(), content)
+			require.NoError(t, err)
+
+			var body struct {
+				Embeds []embed `json:"embeds"`
+			}
+			require.NoError(t, json.Unmarshal(payload, &body))
+
+			fields := body.Embeds[0].Fields
+			assert.LessOrEqual(t, len(fields), 25)
+
+			total := utf8.RuneCountInString(body.Embeds[0].Title)
+			assert.LessOrEqual(t, total, titleLimit)
+			for _, field := range fields {
+				assert.LessOrEqual(t, utf8.RuneCountInString(field.Name), 256)
+				assert.LessOrEqual(t, utf8.RuneCountInString(field.Value), fieldValueLimit)
+				total += utf8.RuneCountInString(field.Name) + utf8.RuneCountInString(field.Value)
+			}
+			assert.LessOrEqual(t, total, 6000)
+		}
+	})
+}
@@ -0,0 +1,342 @@
+package discord
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"log/slog"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/taldoflemis/wilson-bot/internal"
+)
+
+const (
+	interactionTypePing               = 1
+	interactionTypeApplicationCommand = 2
+
+	responseTypePong                     = 1
+	responseTypeChannelMessageWithSource = 4
+
+	messageFlagEphemeral = 1 << 6
+
+	statsLeaderboardSize = 10
+
+	signatureHeader          = "X-Signature-Ed25519"
+	signatureTimestampHeader = "X-Signature-Timestamp"
+)
+
+var (
+	ErrInvalidPublicKey = errors.New("invalid discord public key")
+	ErrInvalidSignature = errors.New("invalid request signature")
+)
+
+type interaction struct {
+	Type   int             `json:"type"`
+	Data   interactionData `json:"data"`
+	Member *struct {
+		User interactionUser `json:"user"`
+	} `json:"member"`
+	User *interactionUser `json:"user"`
+}
+
+type interactionUser struct {
+	ID string `json:"id"`
+}
+
+// userID is the member in a guild and the user in a DM
+func (i interaction) userID() string {
+	if i.Member != nil {
+		return i.Member.User.ID
+	}
+	if i.User != nil {
+		return i.User.ID
+	}
+
+	return ""
+}
+
+type interactionData struct {
+	Name    string              `json:"name"`
+	Options []interactionOption `json:"options"`
+}
+
+type interactionOption struct {
+	Name  string `json:"name"`
+	Value any    `json:"value"`
+}
+
+type interactionResponse struct {
+	Type int             `json:"type"`
+	Data json.RawMessage `json:"data,omitempty"`
+}
+
+type interactionMessage struct {
+	Content string `json:"content"`
+	Flags   int    `json:"flags,omitempty"`
+}
+
+// InteractionsHandler answers the slash commands Discord sends to the
+// interactions endpoint
+type InteractionsHandler struct {
+	publicKey       ed25519.PublicKey
+	cards           *cardRenderer
+	assets          fs.FS
+	messageStorer   internal.MessageStorer
+	messageSender   internal.MessageSender
+	breakageTracker internal.BreakageTracker
+	placeholders    map[string]string
+	background      *internal.Background
+}
+
+var (
+	_ internal.RouteRegisterer = (*InteractionsHandler)(nil)
+)
+
+// NewInteractionsHandler creates the handler, publicKey is the hex encoded
+// application public key shown in the Discord developer portal
+func NewInteractionsHandler(
+	publicKey string,
+	templates *internal.TemplateSet,
+	assets fs.FS,
+	messageStorer internal.MessageStorer,
+	messageSender internal.MessageSender,
+	breakageTracker internal.BreakageTracker,
+	placeholders map[string]string,
+	background *internal.Background,
+) (*InteractionsHandler, error) {
+	key, err := hex.DecodeString(publicKey)
+	if err != nil || len(key) != ed25519.PublicKeySize {
+		return nil, ErrInvalidPublicKey
+	}
+
+	return &InteractionsHandler{
+		publicKey:       key,
+		cards:           newCardRenderer(templates),
+		assets:          assets,
+		messageStorer:   messageStorer,
+		messageSender:   messageSender,
+		breakageTracker: breakageTracker,
+		placeholders:    placeholders,
+		background:      background,
+	}, nil
+}
+
+func (h *InteractionsHandler) RegisterRoutes(g *echo.Group) {
+	g.POST("/discord/interactions", h.HandleInteraction, internal.TriggerMiddleware(internal.TriggerCommand))
+}
+
+// VerifySignature checks the Ed25519 signature Discord sends along every
+// interaction, signed over the timestamp followed by the raw body
+func VerifySignature(publicKey ed25519.PublicKey, header http.Header, body []byte) error {
+	signature, err := hex.DecodeString(header.Get(signatureHeader))
+	if err != nil || len(signature) != ed25519.SignatureSize {
+		return ErrInvalidSignature
+	}
+
+	timestamp := header.Get(signatureTimestampHeader)
+	if timestamp == "" {
+		return ErrInvalidSignature
+	}
+
+	message := append([]byte(timestamp), body...)
+	if !ed25519.Verify(publicKey, message, signature) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func (h *InteractionsHandler) HandleInteraction(c echo.Context) error {
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "invalid request"})
+	}
+
+	err = VerifySignature(h.publicKey, c.Request().Header, body)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": err.Error()})
+	}
+
+	var i interaction
+	if err := json.Unmarshal(body, &i); err != nil {
+		return c.JSON(400, map[string]string{"error": "invalid request"})
+	}
+
+	switch i.Type {
+	case interactionTypePing:
+		return c.JSON(200, interactionResponse{Type: responseTypePong})
+	case interactionTypeApplicationCommand:
+		return h.handleCommand(c, i)
+	default:
+		return c.JSON(400, map[string]string{"error": "unsupported interaction type"})
+	}
+}
+
+func (h *InteractionsHandler) handleCommand(c echo.Context, i interaction) error {
+	ctx := c.Request().Context()
+	data := i.Data
+
+	var (
+		payload  []byte
+		imageURL string
+		err      error
+	)
+
+	switch data.Name {
+	case "wilson":
+		payload, imageURL, err = h.wilsonCommand(c, data, i.userID())
+	case "broken":
+		payload, err = h.brokenCommand(c, data)
+	case "wilson-stats":
+		payload, err = h.statsCommand(c)
+	default:
+		payload, err = ephemeral("Comando desconhecido")
+	}
+
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to handle discord command", slog.String("command", data.Name), slog.Any("error", err))
+		payload, err = ephemeral("Deu ruim, tente novamente mais tarde")
+		if err != nil {
+			return c.JSON(500, map[string]string{"error": err.Error()})
+		}
+		imageURL = ""
+	}
+
+	return h.respond(c, payload, imageURL)
+}
+
+// respond answers with the message, uploading the card image along with it
+// when it is an asset
+func (h *InteractionsHandler) respond(c echo.Context, payload []byte, imageURL string) error {
+	response := interactionResponse{
+		Type: responseTypeChannelMessageWithSource,
+		Data: payload,
+	}
+
+	name, ok := internal.AssetName(imageURL)
+	if !ok {
+		return c.JSON(200, response)
+	}
+
+	body, err := json.Marshal(response)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	file, err := fs.ReadFile(h.assets, name)
+	if err != nil {
+		slog.ErrorContext(c.Request().Context(), "failed to read asset", slog.String("asset", name), slog.Any("error", err))
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	form, contentType, err := multipartBody(body, name, file)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	return c.Stream(200, contentType, form)
+}
+
+// wilsonCommand replies with a random message mentioning who asked for it,
+// it returns the image to upload along with the card
+func (h *InteractionsHandler) wilsonCommand(c echo.Context, data interactionData, userID string) ([]byte, string, error) {
+	ctx := c.Request().Context()
+
+	message, err := internal.GetRandomMessage(ctx, h.messageStorer, option(data, "tag"))
+	if errors.Is(err, internal.ErrMessageNotFound) {
+		payload, err := ephemeral("Nenhuma mensagem encontrada")
+		return payload, "", err
+	}
+	if err != nil {
+		return nil, "", err
+	}
+
+	vars := map[string]string{}
+	if userID != "" {
+		vars["name"] = "<@" + userID + ">"
+	}
+
+	rendered := internal.RenderMessagePlaceholders(ctx, *message, h.placeholders, vars)
+	imageURL := cardImage(rendered)
+
+	payload, err := h.cards.renderMessage(ctx, rendered, internal.NewFitter(internal.LimitModeTruncate))
+	if err != nil {
+		return nil, "", err
+	}
+
+	payload, err = withAttachment(payload, imageURL)
+	if err != nil {
+		return nil, "", err
+	}
+
+	return payload, imageURL, nil
+}
+
+// brokenCommand answers right away, Discord gives up on the interaction after
+// three seconds, and sends the card in the background
+func (h *InteractionsHandler) brokenCommand(c echo.Context, data interactionData) ([]byte, error) {
+	ctx := c.Request().Context()
+
+	name, motive := option(data, "name"), option(data, "motive")
+	if name == "" || motive == "" {
+		return ephemeral("Informe quem quebrou e o motivo")
+	}
+
+	brokenMessage, err := internal.NewBrokenMessage(ctx, h.breakageTracker, name, motive, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	h.background.Go(ctx, "discord broken command", func(ctx context.Context) error {
+		return h.messageSender.SendBrokenMessage(ctx, brokenMessage)
+	})
+
+	return json.Marshal(interactionMessage{
+		Content: fmt.Sprintf("Quebra registrada para **%s**: %s", brokenMessage.Name, brokenMessage.Motive),
+	})
+}
+
+func (h *InteractionsHandler) statsCommand(c echo.Context) ([]byte, error) {
+	leaderboard, err := h.breakageTracker.GetLeaderboard(c.Request().Context())
+	if err != nil {
+		return nil, err
+	}
+
+	if len(leaderboard) == 0 {
+		return json.Marshal(interactionMessage{Content: "Ninguém quebrou nada ainda"})
+	}
+
+	var sb strings.Builder
+	sb.WriteString("**Ranking de quebras**\n")
+	for i, entry := range leaderboard[:min(len(leaderboard), statsLeaderboardSize)] {
+		fmt.Fprintf(&sb, "%d. %s - %d quebra(s)\n", i+1, entry.Name, entry.Breakages)
+	}
+
+	return json.Marshal(interactionMessage{Content: sb.String()})
+}
+
+func option(data interactionData, name string) string {
+	for _, o := range data.Options {
+		if o.Name == name && o.Value != nil {
+			return fmt.Sprint(o.Value)
+		}
+	}
+
+	return ""
+}
+
+func ephemeral(content string) ([]byte, error) {
+	return json.Marshal(interactionMessage{
+		Content: content,
+		Flags:   messageFlagEphemeral,
+	})
+}
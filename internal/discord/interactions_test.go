@@ -0,0 +1,162 @@
+package discord
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"encoding/hex"
+	"encoding/json"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/taldoflemis/wilson-bot/internal"
+)
+
+func newSignedRequest(t *testing.T, privateKey ed25519.PrivateKey, body string) *http.Request {
+	t.Helper()
+
+	timestamp := "1700000000"
+	signature := ed25519.Sign(privateKey, []byte(timestamp+body))
+
+	req := httptest.NewRequest(http.MethodPost, "/discord/interactions", bytes.NewBufferString(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	req.Header.Set(signatureHeader, hex.EncodeToString(signature))
+	req.Header.Set(signatureTimestampHeader, timestamp)
+
+	return req
+}
+
+func newTestInteractionsHandler(
+	t *testing.T,
+	messageStorer internal.MessageStorer,
+	messageSender internal.MessageSender,
+	breakageTracker internal.BreakageTracker,
+) (*InteractionsHandler, ed25519.PrivateKey) {
+	t.Helper()
+
+	publicKey, privateKey, err := ed25519.GenerateKey(nil)
+	require.NoError(t, err)
+
+	handler, err := NewInteractionsHandler(hex.EncodeToString(publicKey), mustTemplates(t), NewAssets(""), messageStorer, messageSender, breakageTracker, nil, internal.NewBackground(time.Minute))
+	require.NoError(t, err)
+
+	return handler, privateKey
+}
+
+func TestNewInteractionsHandlerInvalidKey(t *testing.T) {
+	_, err := NewInteractionsHandler("not hex", nil, nil, nil, nil, nil, nil, nil)
+	assert.ErrorIs(t, err, ErrInvalidPublicKey)
+}
+
+func TestHandleInteractionPing(t *testing.T) {
+	e := echo.New()
+	handler, privateKey := newTestInteractionsHandler(t, nil, nil, nil)
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(newSignedRequest(t, privateKey, `{"type":1}`), rec)
+
+	err := handler.HandleInteraction(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"type":1}`, rec.Body.String())
+}
+
+func TestHandleInteractionInvalidSignature(t *testing.T) {
+	e := echo.New()
+	handler, _ := newTestInteractionsHandler(t, nil, nil, nil)
+	_, otherKey, _ := ed25519.GenerateKey(nil)
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(newSignedRequest(t, otherKey, `{"type":1}`), rec)
+
+	err := handler.HandleInteraction(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestHandleInteractionWilsonCommand(t *testing.T) {
+	e := echo.New()
+	mockStore := internal.NewMockMessageStorer(t)
+	mockStore.On("GetAllMessages", mock.Anything).Return([]internal.Message{
+		{Id: "1", Message: "Não vale uma sibalena vencida", Tags: []string{"general"}},
+		{Id: "2", Message: "Compila na minha máquina", Tags: []string{"tech"}},
+	}, nil)
+
+	handler, privateKey := newTestInteractionsHandler(t, mockStore, nil, nil)
+
+	body := `{"type":2,"data":{"name":"wilson","options":[{"name":"tag","type":3,"value":"tech"}]}}`
+	rec := httptest.NewRecorder()
+	c := e.NewContext(newSignedRequest(t, privateKey, body), rec)
+
+	err := handler.HandleInteraction(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// the default image goes along with the card
+	_, params, err := mime.ParseMediaType(rec.Header().Get(echo.HeaderContentType))
+	require.NoError(t, err)
+	form, err := multipart.NewReader(rec.Body, params["boundary"]).ReadForm(1 << 20)
+	require.NoError(t, err)
+	require.Len(t, form.File["files[0]"], 1)
+	assert.Equal(t, "pepe.png", form.File["files[0]"][0].Filename)
+
+	var resp interactionResponse
+	require.NoError(t, json.Unmarshal([]byte(form.Value["payload_json"][0]), &resp))
+	assert.Equal(t, responseTypeChannelMessageWithSource, resp.Type)
+	assert.Contains(t, string(resp.Data), "Compila na minha m")
+	assert.Contains(t, string(resp.Data), "attachment://pepe.png")
+}
+
+func TestHandleInteractionBrokenCommand(t *testing.T) {
+	e := echo.New()
+	tracker := internal.NewInMemoryBreakageTracker()
+	mockSender := internal.NewMockMessageSender(t)
+	mockSender.On("SendBrokenMessage", mock.Anything, mock.MatchedBy(func(m internal.BrokenMessage) bool {
+		return m.Name == "Wilson" && m.Motive == "Subiu sem testar"
+	})).Return(nil)
+
+	handler, privateKey := newTestInteractionsHandler(t, nil, mockSender, tracker)
+
+	body := `{"type":2,"data":{"name":"broken","options":[{"name":"name","value":"Wilson"},{"name":"motive","value":"Subiu sem testar"}]}}`
+	rec := httptest.NewRecorder()
+	c := e.NewContext(newSignedRequest(t, privateKey, body), rec)
+
+	err := handler.HandleInteraction(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Quebra registrada")
+
+	// the card is sent after the answer
+	handler.background.Wait()
+	mockSender.AssertExpectations(t)
+}
+
+func TestHandleInteractionStatsCommand(t *testing.T) {
+	e := echo.New()
+	tracker := internal.NewInMemoryBreakageTracker()
+	_ = tracker.RecordBreakage(context.Background(), internal.Breakage{Name: "Wilson", BrokenAt: time.Now()})
+
+	handler, privateKey := newTestInteractionsHandler(t, nil, nil, tracker)
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(newSignedRequest(t, privateKey, `{"type":2,"data":{"name":"wilson-stats"}}`), rec)
+
+	err := handler.HandleInteraction(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "1. Wilson - 1 quebra(s)")
+}
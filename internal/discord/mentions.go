@@ -0,0 +1,59 @@
+package discord
+
+import (
+	"encoding/json"
+	"strings"
+
+	"github.com/taldoflemis/wilson-bot/internal"
+)
+
+type allowedMentions struct {
+	Parse []string `json:"parse"`
+	Users []string `json:"users"`
+}
+
+// withMentions puts the mentions in the message content, as Discord doesn't
+// notify the users mentioned inside embeds. Only those users are allowed to
+// be pinged
+func withMentions(payload []byte, members []internal.TeamMember) ([]byte, error) {
+	var (
+		mentions []string
+		users    []string
+	)
+
+	for _, member := range members {
+		if mention := member.DiscordMention(); mention != "" {
+			mentions = append(mentions, mention)
+			users = append(users, member.DiscordID)
+		}
+	}
+
+	if len(mentions) == 0 {
+		return payload, nil
+	}
+
+	var body map[string]json.RawMessage
+	err := json.Unmarshal(payload, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	content := strings.Join(mentions, " ")
+
+	var existing string
+	if raw, ok := body["content"]; ok && json.Unmarshal(raw, &existing) == nil && existing != "" {
+		content += " " + existing
+	}
+
+	body["content"], err = json.Marshal(internal.TruncateText(content, contentLimit))
+	if err != nil {
+		return nil, err
+	}
+
+	body["allowed_mentions"], err = json.Marshal(allowedMentions{Parse: []string{}, Users: users})
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(body)
+}
@@ -0,0 +1,202 @@
+package discord
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/taldoflemis/wilson-bot/internal"
+)
+
+var (
+	ErrInvalidThread = errors.New("a discord thread takes either thread_id or thread_name")
+)
+
+// Discord rejects the thread names past 100 characters
+const threadNameLimit = 100
+
+// unnamedPerson fills {name} when the card has nobody, so the forum post still
+// gets a name
+const unnamedPerson = "Desconhecido"
+
+// thread is where a card is posted, the channel itself when both are empty.
+// webhook is the kind of card posted through its own webhook, empty for the
+// default one
+type thread struct {
+	id      string
+	name    string
+	webhook string
+}
+
+// threads resolves the thread of every kind of card, remembering the forum
+// posts it created so the next cards with the same name reply to them, across
+// restarts when there is a state file
+type threads struct {
+	configs   map[string]internal.DiscordThreadConfig
+	stateFile string
+
+	mu      sync.Mutex
+	created map[string]string
+}
+
+func newThreads(cfg internal.DiscordThreadsConfig) (*threads, error) {
+	configs := map[string]internal.DiscordThreadConfig{
+		internal.SendKindMessage: cfg.Message,
+		internal.SendKindBroken:  cfg.Broken,
+		internal.SendKindDigest:  cfg.Digest,
+		internal.SendKindAlert:   cfg.Alert,
+	}
+
+	for _, c := range configs {
+		if c.ThreadID != "" && c.ThreadName != "" {
+			return nil, ErrInvalidThread
+		}
+	}
+
+	created := make(map[string]string)
+
+	if cfg.StateFile != "" {
+		data, err := os.ReadFile(cfg.StateFile)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			return nil, err
+		}
+
+		if len(data) > 0 {
+			err = json.Unmarshal(data, &created)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	return &threads{
+		configs:   configs,
+		stateFile: cfg.StateFile,
+		created:   created,
+	}, nil
+}
+
+// webhookURL is the webhook of the kind of card, empty for the default one
+func (t *threads) webhookURL(kind string) string {
+	return t.configs[kind].WebhookURL
+}
+
+// resolve returns the thread of the card, thread names may use {name} for
+// the person and {date} for the day
+func (t *threads) resolve(kind string, person string, now time.Time) thread {
+	cfg := t.configs[kind]
+
+	var webhook string
+	if cfg.WebhookURL != "" {
+		webhook = kind
+	}
+
+	if cfg.ThreadName == "" {
+		return thread{id: cfg.ThreadID, webhook: webhook}
+	}
+
+	person = cmp.Or(strings.TrimSpace(person), unnamedPerson)
+	name := strings.NewReplacer("{name}", person, "{date}", now.Format("02/01/2006")).Replace(cfg.ThreadName)
+	name = internal.TruncateText(strings.TrimSpace(name), threadNameLimit)
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// the same name posted through different webhooks lands in different
+	// forums
+	if id, ok := t.created[createdKey(webhook, name)]; ok {
+		return thread{id: id, webhook: webhook}
+	}
+
+	return thread{name: name, webhook: webhook}
+}
+
+// remember keeps the forum post created for the thread name, a failure to
+// save it only costs a duplicate post after a restart
+func (t *threads) remember(th thread, channelID string) error {
+	if th.name == "" || channelID == "" {
+		return nil
+	}
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	t.created[createdKey(th.webhook, th.name)] = channelID
+
+	if t.stateFile == "" {
+		return nil
+	}
+
+	data, err := json.MarshalIndent(t.created, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return internal.WriteFileAtomic(t.stateFile, data)
+}
+
+func createdKey(webhook string, name string) string {
+	if webhook == "" {
+		return name
+	}
+
+	return webhook + ":" + name
+}
+
+// withThreadName asks Discord to create the forum post along with the message
+func withThreadName(payload []byte, name string) ([]byte, error) {
+	if name == "" {
+		return payload, nil
+	}
+
+	var body map[string]json.RawMessage
+	err := json.Unmarshal(payload, &body)
+	if err != nil {
+		return nil, err
+	}
+
+	body["thread_name"], err = json.Marshal(name)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(body)
+}
+
+// postedID holds the thread along with the message id, the webhook only
+// finds messages posted in threads when told the thread. The kind of card
+// prefixes the id when it went through its own webhook, which is the only one
+// able to change it
+func postedID(th thread, messageID string) string {
+	id := messageID
+	if th.id != "" {
+		id = th.id + "/" + messageID
+	}
+
+	if th.webhook != "" {
+		id = th.webhook + ":" + id
+	}
+
+	return id
+}
+
+// parsePostedID returns the thread the message is in, along with the webhook
+// that posted it
+func parsePostedID(id string) (th thread, messageID string) {
+	if webhook, rest, ok := strings.Cut(id, ":"); ok {
+		th.webhook, id = webhook, rest
+	}
+
+	threadID, messageID, ok := strings.Cut(id, "/")
+	if !ok {
+		return th, id
+	}
+
+	th.id = threadID
+
+	return th, messageID
+}
@@ -0,0 +1,118 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"maps"
+	"sync"
+
+	"github.com/labstack/echo/v4"
+)
+
+// DryRunPayloads collects the payloads rendered by the dry run senders while
+// handling a single send
+type DryRunPayloads struct {
+	mu       sync.Mutex
+	payloads map[string]json.RawMessage
+}
+
+func (d *DryRunPayloads) add(platform string, payload []byte) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	if d.payloads == nil {
+		d.payloads = make(map[string]json.RawMessage)
+	}
+
+	d.payloads[platform] = json.RawMessage(payload)
+}
+
+// Payloads returns the rendered payloads by platform, nil if every sender
+// really delivered
+func (d *DryRunPayloads) Payloads() map[string]json.RawMessage {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	return maps.Clone(d.payloads)
+}
+
+type dryRunKey struct{}
+
+// WithDryRunPayloads attaches a collector to the context, reusing the one
+// already attached
+func WithDryRunPayloads(ctx context.Context) (context.Context, *DryRunPayloads) {
+	if payloads, ok := ctx.Value(dryRunKey{}).(*DryRunPayloads); ok {
+		return ctx, payloads
+	}
+
+	payloads := &DryRunPayloads{}
+
+	return context.WithValue(ctx, dryRunKey{}, payloads), payloads
+}
+
+// DryRunMessageSender renders the payloads of a platform and logs them
+// instead of delivering
+type DryRunMessageSender struct {
+	platform string
+	renderer Renderer
+}
+
+var (
+	_ MessageSender = (*DryRunMessageSender)(nil)
+)
+
+func NewDryRunMessageSender(platform string, renderer Renderer) *DryRunMessageSender {
+	return &DryRunMessageSender{
+		platform: platform,
+		renderer: renderer,
+	}
+}
+
+func (s *DryRunMessageSender) SendMessage(ctx context.Context, message Message) error {
+	return s.render(ctx, message)
+}
+
+func (s *DryRunMessageSender) SendBrokenMessage(ctx context.Context, message BrokenMessage) error {
+	return s.render(ctx, message)
+}
+
+func (s *DryRunMessageSender) SendDigest(ctx context.Context, digest Digest) error {
+	return s.render(ctx, digest)
+}
+
+func (s *DryRunMessageSender) SendAlert(ctx context.Context, notification AlertNotification) error {
+	return s.render(ctx, notification)
+}
+
+func (s *DryRunMessageSender) render(ctx context.Context, content any) error {
+	payload, err := s.renderer.Render(ctx, content)
+	if err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "dry run, payload not sent",
+		slog.String("platform", s.platform),
+		slog.String("payload", string(payload)))
+
+	if payloads, ok := ctx.Value(dryRunKey{}).(*DryRunPayloads); ok {
+		payloads.add(s.platform, payload)
+	}
+
+	return nil
+}
+
+// sentResponse answers a successful send, adding the rendered payloads when
+// some sender is in dry run
+func sentResponse(c echo.Context, payloads *DryRunPayloads, message string) error {
+	rendered := payloads.Payloads()
+	if len(rendered) == 0 {
+		return c.JSON(200, map[string]string{"message": message})
+	}
+
+	return c.JSON(200, map[string]any{
+		"message":  message,
+		"dry_run":  true,
+		"payloads": rendered,
+	})
+}
@@ -0,0 +1,68 @@
+package internal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDryRunSendMessageById(t *testing.T) {
+	// nothing listens there, a real delivery would fail
+	googleChat, err := NewHardcodedGoogleChatProvider("http://127.0.0.1:1/webhook", mustGoogleChatTemplates(t), GoogleChatThreadsConfig{}, LimitModeTruncate)
+	require.NoError(t, err)
+
+	history := NewInMemoryHistoryStorer()
+	sender := NewRecordingMessageSender(
+		NewMultiMessageSender(NewDryRunMessageSender("googlechat", googleChat)),
+		history,
+		NewInMemoryBreakageTracker(),
+		nil,
+	)
+
+	e := echo.New()
+	mockStore := NewMockMessageStorer(t)
+	mockStore.On("GetMessageByID", mock.Anything, "1").Return(&Message{Id: "1", Message: "Obrigado, Wilson"}, nil)
+
+	server := &Server{messageStorer: mockStore, messageSender: sender, sendMessages: true, echoServer: e}
+
+	req := httptest.NewRequest(http.MethodPost, "/messages/1", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+
+	err = server.SendMessageById(c)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var body struct {
+		DryRun   bool                       `json:"dry_run"`
+		Payloads map[string]json.RawMessage `json:"payloads"`
+	}
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.True(t, body.DryRun)
+	assert.Contains(t, string(body.Payloads["googlechat"]), "Obrigado, Wilson")
+
+	records, err := history.GetSendRecords(t.Context(), time.Time{}, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Contains(t, records[0].DryRunPayloads, "googlechat")
+}
+
+func TestSentResponseWithoutDryRun(t *testing.T) {
+	e := echo.New()
+	rec := httptest.NewRecorder()
+	_, payloads := WithDryRunPayloads(t.Context())
+
+	err := sentResponse(e.NewContext(httptest.NewRequest(http.MethodPost, "/", nil), rec), payloads, "message sent")
+
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"message": "message sent"}`, rec.Body.String())
+}
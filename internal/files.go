@@ -0,0 +1,26 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// WriteFileAtomic writes the data aside and renames it over path, so a crash
+// never leaves half a file
+func WriteFileAtomic(path string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(path), filepath.Base(path)+".*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
@@ -0,0 +1,217 @@
+package internal
+
+import (
+	"context"
+	"embed"
+	"log/slog"
+)
+
+const (
+	cardTemplateName       = "card_template.json"
+	brokenCardTemplateName = "broken_card_template.json"
+	digestCardTemplateName = "digest_card_template.json"
+	alertCardTemplateName  = "alert_card_template.json"
+)
+
+//go:embed card_template.json broken_card_template.json digest_card_template.json alert_card_template.json
+var googleChatTemplates embed.FS
+
+type templateData struct {
+	Message  string
+	ImageURL string
+	ID       string
+}
+
+type brokenTemplateData struct {
+	ID              string
+	Name            string
+	Motive          string
+	TimeSinceBroken string
+	DayOfBreakage   string
+	ImageURL        string
+}
+
+type digestTemplateData struct {
+	ID           string
+	Title        string
+	Interval     string
+	MessagesSent int
+	TopTags      string
+	Leader       string
+	Breakages    []brokenTemplateData
+	ImageURL     string
+}
+
+type alertItemTemplateData struct {
+	Name     string
+	Severity string
+	Summary  string
+	StartsAt string
+}
+
+type alertTemplateData struct {
+	ID          string
+	Title       string
+	Status      string
+	Color       int
+	HexColor    string
+	ExternalURL string
+	OnCall      string
+	Roast       string
+	Alerts      []alertItemTemplateData
+}
+
+// Google Chat card limits, a message can't go past 32000 bytes so the cards
+// listing many items share a budget
+const (
+	googleChatTextLimit          = 4096
+	googleChatItemsBudget        = 16000
+	googleChatDigestTextLimit    = 200
+	googleChatDigestMaxBreakages = 20
+	googleChatAlertNameLimit     = 200
+	googleChatAlertMaxItems      = 10
+)
+
+// NewGoogleChatTemplates loads the Google Chat card templates from dir,
+// falling back to the embedded ones for the files missing there
+func NewGoogleChatTemplates(dir string) (*TemplateSet, error) {
+	return NewTemplateSet("googlechat", dir, googleChatTemplates, map[string]any{
+		cardTemplateName:       templateData{},
+		brokenCardTemplateName: brokenTemplateData{},
+		digestCardTemplateName: digestTemplateData{Breakages: []brokenTemplateData{{}}},
+		alertCardTemplateName:  alertTemplateData{Alerts: []alertItemTemplateData{{}, {}}, Roast: "-"},
+	})
+}
+
+// googleChatCardRenderer renders the Google Chat cardsV2 payloads, shared by
+// the webhook sender and the app events handler
+type googleChatCardRenderer struct {
+	templates *TemplateSet
+}
+
+func newGoogleChatCardRenderer(templates *TemplateSet) *googleChatCardRenderer {
+	return &googleChatCardRenderer{
+		templates: templates,
+	}
+}
+
+func (r *googleChatCardRenderer) renderMessage(ctx context.Context, message Message, fit *Fitter) ([]byte, error) {
+	ctx, span := Tracer().Start(ctx, "googleChatCardRenderer.renderMessage")
+	defer span.End()
+
+	data := templateData{
+		Message:  fit.Fit(message.Message, googleChatTextLimit),
+		ImageURL: ImageOrDefault(message.ImageURL, DefaultMessageImageURL),
+	}
+
+	payload, err := r.templates.Execute(cardTemplateName, data)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to execute template", slog.Any("error", err))
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+func (r *googleChatCardRenderer) renderBrokenMessage(ctx context.Context, message BrokenMessage, fit *Fitter) ([]byte, error) {
+	ctx, span := Tracer().Start(ctx, "googleChatCardRenderer.renderBrokenMessage")
+	defer span.End()
+
+	data := brokenTemplateData{
+		ID:              message.Id,
+		Name:            TruncateText(message.Name, googleChatTextLimit),
+		Motive:          fit.Fit(message.Motive, googleChatTextLimit),
+		TimeSinceBroken: message.TimeSinceBroken,
+		DayOfBreakage:   message.DayOfBreakage,
+		ImageURL:        ImageOrDefault(message.ImageURL, DefaultBrokenImageURL),
+	}
+
+	payload, err := r.templates.Execute(brokenCardTemplateName, data)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to execute template", slog.Any("error", err))
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// renderDigest lists the first breakages only, with their motives truncated
+func (r *googleChatCardRenderer) renderDigest(ctx context.Context, digest Digest) ([]byte, error) {
+	ctx, span := Tracer().Start(ctx, "googleChatCardRenderer.renderDigest")
+	defer span.End()
+
+	data := digestTemplateData{
+		ID:           digest.Id,
+		Title:        digest.Title(),
+		Interval:     digest.Interval(),
+		MessagesSent: digest.MessagesSent,
+		TopTags:      digest.TopTagsSummary(),
+		Leader:       digest.LeaderSummary(),
+		ImageURL:     ImageOrDefault(digest.ImageURL, DefaultMessageImageURL),
+	}
+
+	for _, b := range digest.Breakages[:min(len(digest.Breakages), googleChatDigestMaxBreakages)] {
+		data.Breakages = append(data.Breakages, brokenTemplateData{
+			ID:            b.Id,
+			Name:          TruncateText(b.Name, googleChatDigestTextLimit),
+			Motive:        TruncateText(b.Motive, googleChatDigestTextLimit),
+			DayOfBreakage: b.BrokenAt.Format("02/01/2006"),
+		})
+	}
+
+	payload, err := r.templates.Execute(digestCardTemplateName, data)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to execute template", slog.Any("error", err))
+		return nil, err
+	}
+
+	return payload, nil
+}
+
+// renderAlert lists the first alerts only, sharing the items budget between
+// their summaries
+func (r *googleChatCardRenderer) renderAlert(ctx context.Context, notification AlertNotification, fit *Fitter) ([]byte, error) {
+	ctx, span := Tracer().Start(ctx, "googleChatCardRenderer.renderAlert")
+	defer span.End()
+
+	status := "Disparando"
+	if notification.Status == AlertStatusResolved {
+		status = "Resolvido"
+	}
+
+	data := alertTemplateData{
+		ID:          notification.Id,
+		Title:       notification.Title(),
+		Status:      status,
+		Color:       notification.Color(),
+		HexColor:    notification.HexColor(),
+		ExternalURL: notification.ExternalURL,
+		OnCall:      notification.OnCall,
+		Roast:       fit.Fit(notification.Roast, googleChatTextLimit),
+	}
+
+	alerts := notification.Alerts[:min(len(notification.Alerts), googleChatAlertMaxItems)]
+	summaryLimit := min(googleChatTextLimit, googleChatItemsBudget/max(len(alerts), 1))
+
+	for _, a := range alerts {
+		summary := a.Summary
+		if summary == "" {
+			summary = a.Description
+		}
+
+		data.Alerts = append(data.Alerts, alertItemTemplateData{
+			Name:     TruncateText(a.Name, googleChatAlertNameLimit),
+			Severity: a.Severity,
+			Summary:  TruncateText(summary, summaryLimit),
+			StartsAt: a.StartsAt.Format("02/01/2006 15:04"),
+		})
+	}
+
+	payload, err := r.templates.Execute(alertCardTemplateName, data)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to execute template", slog.Any("error", err))
+		return nil, err
+	}
+
+	return payload, nil
+}
@@ -0,0 +1,217 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	googleChatEventMessage      = "MESSAGE"
+	googleChatEventAddedToSpace = "ADDED_TO_SPACE"
+
+	googleChatCommandWilson = "wilson"
+	googleChatCommandBroken = "broken"
+	googleChatCommandStats  = "wilson-stats"
+)
+
+type googleChatEvent struct {
+	Type    string            `json:"type"`
+	Message googleChatMessage `json:"message"`
+	Space   struct {
+		Name string `json:"name"`
+		Type string `json:"type"`
+	} `json:"space"`
+	User struct {
+		Name        string `json:"name"`
+		DisplayName string `json:"displayName"`
+	} `json:"user"`
+}
+
+type googleChatMessage struct {
+	Text         string `json:"text"`
+	ArgumentText string `json:"argumentText"`
+	SlashCommand *struct {
+		CommandID json.Number `json:"commandId"`
+	} `json:"slashCommand"`
+}
+
+type googleChatTextReply struct {
+	Text string `json:"text"`
+}
+
+// GoogleChatEventsHandler answers the events Google Chat sends to the app, as
+// @mentions and slash commands, with synchronous replies
+type GoogleChatEventsHandler struct {
+	verifier        *JWKSVerifier
+	cards           *googleChatCardRenderer
+	commands        map[string]string
+	messageStorer   MessageStorer
+	messageSender   MessageSender
+	breakageTracker BreakageTracker
+	placeholders    map[string]string
+	background      *Background
+}
+
+var (
+	_ RouteRegisterer = (*GoogleChatEventsHandler)(nil)
+)
+
+func NewGoogleChatEventsHandler(
+	cfg GoogleChatEventsConfig,
+	templates *TemplateSet,
+	messageStorer MessageStorer,
+	messageSender MessageSender,
+	breakageTracker BreakageTracker,
+	placeholders map[string]string,
+	background *Background,
+) (*GoogleChatEventsHandler, error) {
+	return &GoogleChatEventsHandler{
+		verifier: NewJWKSVerifier(cfg.JWKSURL, cfg.Issuer, cfg.Audience),
+		cards:    newGoogleChatCardRenderer(templates),
+		commands: map[string]string{
+			cfg.WilsonCommandID: googleChatCommandWilson,
+			cfg.BrokenCommandID: googleChatCommandBroken,
+			cfg.StatsCommandID:  googleChatCommandStats,
+		},
+		messageStorer:   messageStorer,
+		messageSender:   messageSender,
+		breakageTracker: breakageTracker,
+		placeholders:    placeholders,
+		background:      background,
+	}, nil
+}
+
+func (h *GoogleChatEventsHandler) RegisterRoutes(g *echo.Group) {
+	g.POST("/googlechat/events", h.HandleEvent, TriggerMiddleware(TriggerCommand))
+}
+
+func (h *GoogleChatEventsHandler) HandleEvent(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	token, ok := strings.CutPrefix(c.Request().Header.Get(echo.HeaderAuthorization), "Bearer ")
+	if !ok {
+		return c.JSON(401, map[string]string{"error": "missing bearer token"})
+	}
+
+	err := h.verifier.Verify(ctx, token)
+	if err != nil {
+		slog.WarnContext(ctx, "rejected google chat event", slog.Any("error", err))
+		return c.JSON(401, map[string]string{"error": "invalid bearer token"})
+	}
+
+	var event googleChatEvent
+	if err := c.Bind(&event); err != nil {
+		return c.JSON(400, map[string]string{"error": "invalid request"})
+	}
+
+	var reply []byte
+
+	switch event.Type {
+	case googleChatEventAddedToSpace:
+		reply, err = json.Marshal(googleChatTextReply{
+			Text: "Obrigado por adicionar o Wilson! Me mencione com @Wilson para receber uma mensagem.",
+		})
+	case googleChatEventMessage:
+		reply, err = h.handleMessage(ctx, event)
+	default:
+		return c.JSON(200, map[string]string{})
+	}
+
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to handle google chat event", slog.String("type", event.Type), slog.Any("error", err))
+		return c.JSON(200, googleChatTextReply{Text: "Deu ruim, tente novamente mais tarde"})
+	}
+
+	return c.JSONBlob(200, reply)
+}
+
+func (h *GoogleChatEventsHandler) handleMessage(ctx context.Context, event googleChatEvent) ([]byte, error) {
+	message := event.Message
+	args := strings.TrimSpace(message.ArgumentText)
+
+	command := googleChatCommandWilson
+	if message.SlashCommand != nil {
+		command = h.commands[message.SlashCommand.CommandID.String()]
+	}
+
+	switch command {
+	case googleChatCommandWilson:
+		return h.wilsonCommand(ctx, args, event.User.DisplayName)
+	case googleChatCommandBroken:
+		return h.brokenCommand(ctx, args)
+	case googleChatCommandStats:
+		return h.statsCommand(ctx)
+	default:
+		return json.Marshal(googleChatTextReply{Text: "Comando desconhecido"})
+	}
+}
+
+// wilsonCommand replies with a random message, using the arguments as a tag
+// filter and falling back to any message when nothing matches. The message is
+// addressed to whoever asked for it
+func (h *GoogleChatEventsHandler) wilsonCommand(ctx context.Context, tag string, name string) ([]byte, error) {
+	message, err := GetRandomMessage(ctx, h.messageStorer, tag)
+	if errors.Is(err, ErrMessageNotFound) && tag != "" {
+		message, err = GetRandomMessage(ctx, h.messageStorer, "")
+	}
+	if errors.Is(err, ErrMessageNotFound) {
+		return json.Marshal(googleChatTextReply{Text: "Nenhuma mensagem encontrada"})
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rendered := RenderMessagePlaceholders(ctx, *message, h.placeholders, map[string]string{"name": name})
+
+	return h.cards.renderMessage(ctx, rendered, NewFitter(LimitModeTruncate))
+}
+
+// brokenCommand expects the name of who broke it followed by the motive, the
+// card is sent in the background as Google Chat waits 30 seconds at most for
+// the reply
+func (h *GoogleChatEventsHandler) brokenCommand(ctx context.Context, args string) ([]byte, error) {
+	name, motive, _ := strings.Cut(args, " ")
+	motive = strings.TrimSpace(motive)
+	if name == "" || motive == "" {
+		return json.Marshal(googleChatTextReply{Text: "Uso: /broken <nome> <motivo>"})
+	}
+
+	brokenMessage, err := NewBrokenMessage(ctx, h.breakageTracker, name, motive, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	h.background.Go(ctx, "google chat broken command", func(ctx context.Context) error {
+		return h.messageSender.SendBrokenMessage(ctx, brokenMessage)
+	})
+
+	return json.Marshal(googleChatTextReply{
+		Text: fmt.Sprintf("Quebra registrada para *%s*: %s", brokenMessage.Name, brokenMessage.Motive),
+	})
+}
+
+func (h *GoogleChatEventsHandler) statsCommand(ctx context.Context) ([]byte, error) {
+	leaderboard, err := h.breakageTracker.GetLeaderboard(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(leaderboard) == 0 {
+		return json.Marshal(googleChatTextReply{Text: "Ninguém quebrou nada ainda"})
+	}
+
+	var sb strings.Builder
+	sb.WriteString("*Ranking de quebras*\n")
+	for i, entry := range leaderboard[:min(len(leaderboard), 10)] {
+		fmt.Fprintf(&sb, "%d. %s - %d quebra(s)\n", i+1, entry.Name, entry.Breakages)
+	}
+
+	return json.Marshal(googleChatTextReply{Text: sb.String()})
+}
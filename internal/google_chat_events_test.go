@@ -0,0 +1,132 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestGoogleChatEventsHandler(
+	t *testing.T,
+	messageStorer MessageStorer,
+	messageSender MessageSender,
+	breakageTracker BreakageTracker,
+) (*GoogleChatEventsHandler, string) {
+	t.Helper()
+
+	stub := newJWKSStub(t)
+	cfg := GoogleChatEventsConfig{
+		JWKSURL:         stub.server.URL,
+		Issuer:          "chat@system.gserviceaccount.com",
+		Audience:        "1234",
+		WilsonCommandID: "1",
+		BrokenCommandID: "2",
+		StatsCommandID:  "3",
+	}
+
+	handler, err := NewGoogleChatEventsHandler(cfg, mustGoogleChatTemplates(t), messageStorer, messageSender, breakageTracker, nil, NewBackground(time.Minute))
+	require.NoError(t, err)
+
+	token := stub.sign(t, map[string]any{
+		"iss": cfg.Issuer,
+		"aud": cfg.Audience,
+		"exp": time.Now().Add(time.Hour).Unix(),
+	})
+
+	return handler, token
+}
+
+func newGoogleChatEventRequest(token string, body string) *http.Request {
+	req := httptest.NewRequest(http.MethodPost, "/googlechat/events", bytes.NewBufferString(body))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	if token != "" {
+		req.Header.Set(echo.HeaderAuthorization, "Bearer "+token)
+	}
+
+	return req
+}
+
+func TestHandleGoogleChatEventUnauthorized(t *testing.T) {
+	e := echo.New()
+	handler, _ := newTestGoogleChatEventsHandler(t, nil, nil, nil)
+
+	for _, token := range []string{"", "invalid.jwt.token"} {
+		rec := httptest.NewRecorder()
+		c := e.NewContext(newGoogleChatEventRequest(token, `{"type":"MESSAGE"}`), rec)
+
+		err := handler.HandleEvent(c)
+
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	}
+}
+
+func TestHandleGoogleChatEventAddedToSpace(t *testing.T) {
+	e := echo.New()
+	handler, token := newTestGoogleChatEventsHandler(t, nil, nil, nil)
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(newGoogleChatEventRequest(token, `{"type":"ADDED_TO_SPACE"}`), rec)
+
+	err := handler.HandleEvent(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Obrigado por adicionar o Wilson")
+}
+
+func TestHandleGoogleChatEventMention(t *testing.T) {
+	e := echo.New()
+	mockStore := NewMockMessageStorer(t)
+	mockStore.On("GetAllMessages", mock.Anything).Return([]Message{
+		{Id: "1", Message: "Compila na minha máquina", Tags: []string{"tech"}},
+	}, nil)
+
+	handler, token := newTestGoogleChatEventsHandler(t, mockStore, nil, nil)
+
+	body := `{"type":"MESSAGE","message":{"text":"@Wilson tech","argumentText":" tech"}}`
+	rec := httptest.NewRecorder()
+	c := e.NewContext(newGoogleChatEventRequest(token, body), rec)
+
+	err := handler.HandleEvent(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var reply map[string]any
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &reply))
+	assert.Contains(t, reply, "cardsV2")
+	assert.Contains(t, rec.Body.String(), "Compila na minha máquina")
+}
+
+func TestHandleGoogleChatEventBrokenSlashCommand(t *testing.T) {
+	e := echo.New()
+	mockSender := NewMockMessageSender(t)
+	mockSender.On("SendBrokenMessage", mock.Anything, mock.MatchedBy(func(m BrokenMessage) bool {
+		return m.Name == "Wilson" && m.Motive == "Subiu sem testar"
+	})).Return(nil)
+
+	handler, token := newTestGoogleChatEventsHandler(t, nil, mockSender, NewInMemoryBreakageTracker())
+
+	body := `{"type":"MESSAGE","message":{"argumentText":"Wilson Subiu sem testar","slashCommand":{"commandId":"2"}}}`
+	rec := httptest.NewRecorder()
+	c := e.NewContext(newGoogleChatEventRequest(token, body), rec)
+
+	err := handler.HandleEvent(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Quebra registrada")
+
+	// the card is sent after the reply
+	handler.background.Wait()
+	mockSender.AssertExpectations(t)
+}
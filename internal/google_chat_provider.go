@@ -3,125 +3,232 @@ package internal
 import (
 	"bytes"
 	"context"
-	_ "embed"
+	"encoding/json"
 	"errors"
 	"log/slog"
 	"net/http"
-	"text/template"
+	"net/url"
+	"strings"
+	"time"
 )
 
-//go:embed card_template.json
-var cardTemplate []byte
-
-//go:embed broken_card_template.json
-var brokenCardTemplate []byte
-
 type MessageSender interface {
 	SendMessage(ctx context.Context, message Message) error
 	SendBrokenMessage(ctx context.Context, message BrokenMessage) error
+	SendDigest(ctx context.Context, digest Digest) error
+	SendAlert(ctx context.Context, notification AlertNotification) error
 }
 
-type HardcodedGoogleChatWebhookMessageSender struct {
-	webhookURL         string
-	pearlCardTemplate  *template.Template
-	brokenCardTemplate *template.Template
-	httpClient         *http.Client
-}
+// googleChatPlatform names the Google Chat sender in the send history
+const googleChatPlatform = "googlechat"
 
-type templateData struct {
-	Message string
-	ID      string
-}
-
-type brokenTemplateData struct {
-	ID              string
-	Name            string
-	Motive          string
-	TimeSinceBroken string
-	DayOfBreakage   string
+type HardcodedGoogleChatWebhookMessageSender struct {
+	webhookURL string
+	cards      *googleChatCardRenderer
+	threads    *googleChatThreads
+	limitMode  LimitMode
+	httpClient *http.Client
 }
 
 var (
 	_ MessageSender = (*HardcodedGoogleChatWebhookMessageSender)(nil)
+	_ Renderer      = (*HardcodedGoogleChatWebhookMessageSender)(nil)
 )
 
-func NewHardcodedGoogleChatProvider(webhookURL string) (*HardcodedGoogleChatWebhookMessageSender, error) {
-	tmpl, err := template.New("email_body.tmpl.xml").Parse(string(cardTemplate))
+func NewHardcodedGoogleChatProvider(
+	webhookURL string,
+	templates *TemplateSet,
+	threadsCfg GoogleChatThreadsConfig,
+	limitMode LimitMode,
+) (*HardcodedGoogleChatWebhookMessageSender, error) {
+	threads, err := newGoogleChatThreads(threadsCfg)
 	if err != nil {
 		return nil, err
 	}
 
-	brokenTmpl, err := template.New("broken.tmpl.json").Parse(string(brokenCardTemplate))
+	err = limitMode.Validate()
 	if err != nil {
-		slog.Error("failed to parse broken card template", slog.Any("error", err))
 		return nil, err
 	}
 
 	return &HardcodedGoogleChatWebhookMessageSender{
-		webhookURL:         webhookURL,
-		pearlCardTemplate:  tmpl,
-		brokenCardTemplate: brokenTmpl,
-		httpClient:         &http.Client{},
+		webhookURL: webhookURL,
+		cards:      newGoogleChatCardRenderer(templates),
+		threads:    threads,
+		limitMode:  limitMode,
+		httpClient: NewTracedHTTPClient(0),
 	}, nil
 }
 
 func (h *HardcodedGoogleChatWebhookMessageSender) SendMessage(ctx context.Context, message Message) error {
-	data := templateData{
-		Message: message.Message,
+	return h.send(ctx, message, h.threads.threadKey(SendKindMessage, "", time.Now()))
+}
+
+// SendBrokenMessage implements GoogleChatProvider.
+func (h *HardcodedGoogleChatWebhookMessageSender) SendBrokenMessage(ctx context.Context, message BrokenMessage) error {
+	return h.send(ctx, message, h.threads.threadKey(SendKindBroken, message.Name, time.Now()))
+}
+
+// SendDigest implements MessageSender.
+func (h *HardcodedGoogleChatWebhookMessageSender) SendDigest(ctx context.Context, digest Digest) error {
+	return h.send(ctx, digest, h.threads.threadKey(SendKindDigest, "", time.Now()))
+}
+
+// SendAlert implements MessageSender.
+func (h *HardcodedGoogleChatWebhookMessageSender) SendAlert(ctx context.Context, notification AlertNotification) error {
+	return h.send(ctx, notification, h.threads.threadKey(SendKindAlert, "", time.Now()))
+}
+
+// Render implements Renderer, it returns the exact body the webhook
+// receives for the content, leaving out the follow ups of a split text
+func (h *HardcodedGoogleChatWebhookMessageSender) Render(ctx context.Context, content any) ([]byte, error) {
+	return h.render(ctx, content, NewFitter(h.limitMode))
+}
+
+func (h *HardcodedGoogleChatWebhookMessageSender) render(ctx context.Context, content any, fit *Fitter) ([]byte, error) {
+	var (
+		payload []byte
+		err     error
+	)
+
+	switch c := content.(type) {
+	case Message:
+		payload, err = h.cards.renderMessage(ctx, c, fit)
+	case BrokenMessage:
+		payload, err = h.cards.renderBrokenMessage(ctx, c, fit)
+	case Digest:
+		payload, err = h.cards.renderDigest(ctx, c)
+	case AlertNotification:
+		payload, err = h.cards.renderAlert(ctx, c, fit)
+	default:
+		return nil, ErrUnsupportedContent
 	}
 
-	var buf bytes.Buffer
+	if err != nil {
+		return nil, err
+	}
+
+	return withGoogleChatMentions(payload, Mentions(ctx))
+}
 
-	err := h.pearlCardTemplate.Execute(&buf, data)
+// withGoogleChatMentions puts the mentions in the message text, the cards
+// can't mention users
+func withGoogleChatMentions(payload []byte, members []TeamMember) ([]byte, error) {
+	var mentions []string
+	for _, member := range members {
+		if mention := member.GoogleChatMention(); mention != "" {
+			mentions = append(mentions, mention)
+		}
+	}
+
+	if len(mentions) == 0 {
+		return payload, nil
+	}
+
+	var body map[string]json.RawMessage
+	err := json.Unmarshal(payload, &body)
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to execute template", slog.Any("error", err))
-		return err
+		return nil, err
+	}
+
+	text := strings.Join(mentions, " ")
+
+	var existing string
+	if raw, ok := body["text"]; ok && json.Unmarshal(raw, &existing) == nil && existing != "" {
+		text += " " + existing
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.webhookURL, &buf)
+	body["text"], err = json.Marshal(TruncateText(text, googleChatTextLimit))
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to create request", slog.Any("error", err))
-		return err
+		return nil, err
 	}
 
-	req.Header.Set("Content-Type", "application/json")
+	return json.Marshal(body)
+}
 
-	resp, err := h.httpClient.Do(req)
+// send posts the card followed by the texts that didn't fit in it, replying
+// into the thread of the card
+func (h *HardcodedGoogleChatWebhookMessageSender) send(ctx context.Context, content any, threadKey string) error {
+	fit := NewFitter(h.limitMode)
+
+	payload, err := h.render(ctx, content, fit)
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to send request", slog.Any("error", err))
 		return err
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		slog.ErrorContext(ctx, "unexpected status code", slog.Any("status_code", resp.StatusCode))
-		return errors.New("unexpected status code")
+	posted, err := h.post(ctx, payload, threadKeyQuery(threadKey))
+	if err != nil {
+		return err
+	}
+
+	for _, text := range fit.FollowUps(googleChatTextLimit) {
+		followUp := map[string]any{"text": text}
+
+		query := threadKeyQuery(threadKey)
+		if posted.Thread.Name != "" {
+			// the card may have started its own thread, which has no key
+			followUp["thread"] = map[string]string{"name": posted.Thread.Name}
+			query.Set("messageReplyOption", replyOption)
+		}
+
+		payload, err := json.Marshal(followUp)
+		if err != nil {
+			return err
+		}
+
+		_, err = h.post(ctx, payload, query)
+		if err != nil {
+			return err
+		}
 	}
 
 	return nil
 }
 
-// SendBrokenMessage implements GoogleChatProvider.
-func (h *HardcodedGoogleChatWebhookMessageSender) SendBrokenMessage(ctx context.Context, message BrokenMessage) error {
-	data := brokenTemplateData{
-		ID:              message.Id,
-		Name:            message.Name,
-		Motive:          message.Motive,
-		TimeSinceBroken: message.TimeSinceBroken,
-		DayOfBreakage:   message.DayOfBreakage,
+// CheckHealth implements HealthChecker.
+func (h *HardcodedGoogleChatWebhookMessageSender) CheckHealth(ctx context.Context) error {
+	return ValidateWebhookURL(h.webhookURL)
+}
+
+// threadKeyQuery replies into the thread of the key, when there is one
+func threadKeyQuery(threadKey string) url.Values {
+	query := url.Values{}
+	if threadKey != "" {
+		query.Set("threadKey", threadKey)
+		query.Set("messageReplyOption", replyOption)
 	}
 
-	var buf bytes.Buffer
+	return query
+}
 
-	err := h.brokenCardTemplate.Execute(&buf, data)
+// postedGoogleChatMessage is the part of the created message the sender needs
+type postedGoogleChatMessage struct {
+	Name   string `json:"name"`
+	Thread struct {
+		Name string `json:"name"`
+	} `json:"thread"`
+}
+
+// post records the name of the created message, it returns the thread the
+// message landed in
+func (h *HardcodedGoogleChatWebhookMessageSender) post(ctx context.Context, payload []byte, query url.Values) (postedGoogleChatMessage, error) {
+	var posted postedGoogleChatMessage
+
+	u, err := url.Parse(h.webhookURL)
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to execute template", slog.Any("error", err))
-		return err
+		return posted, errors.New("invalid webhook url")
 	}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodPost, h.webhookURL, &buf)
+	values := u.Query()
+	for key, value := range query {
+		values[key] = value
+	}
+	u.RawQuery = values.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, u.String(), bytes.NewReader(payload))
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to create request", slog.Any("error", err))
-		return err
+		return posted, err
 	}
 
 	req.Header.Set("Content-Type", "application/json")
@@ -129,13 +236,23 @@ func (h *HardcodedGoogleChatWebhookMessageSender) SendBrokenMessage(ctx context.
 	resp, err := h.httpClient.Do(req)
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to send request", slog.Any("error", err))
-		return err
+		return posted, err
 	}
+	defer resp.Body.Close()
 
 	if resp.StatusCode != http.StatusOK {
 		slog.ErrorContext(ctx, "unexpected status code", slog.Any("status_code", resp.StatusCode))
-		return errors.New("unexpected status code")
+		return posted, errors.New("unexpected status code")
 	}
 
-	return nil
+	err = json.NewDecoder(resp.Body).Decode(&posted)
+	if err != nil || posted.Name == "" {
+		// the message is already out, it just can't be found later
+		slog.WarnContext(ctx, "failed to read posted message name", slog.Any("error", err))
+		return posted, nil
+	}
+
+	RecordPostedMessage(ctx, googleChatPlatform, posted.Name)
+
+	return posted, nil
 }
@@ -0,0 +1,190 @@
+package internal
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// containsText looks for a decoded JSON string holding the text
+func containsText(v any, text string) bool {
+	switch v := v.(type) {
+	case string:
+		return strings.Contains(v, text)
+	case []any:
+		for _, e := range v {
+			if containsText(e, text) {
+				return true
+			}
+		}
+	case map[string]any:
+		for _, e := range v {
+			if containsText(e, text) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+func TestGoogleChatSenderRoundTripsEveryMessage(t *testing.T) {
+	var delivered []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	sender, err := NewHardcodedGoogleChatProvider(srv.URL, mustGoogleChatTemplates(t), GoogleChatThreadsConfig{}, LimitModeTruncate)
+	require.NoError(t, err)
+
+	messages, err := GetMessages(t.Context(), RawMessages)
+	require.NoError(t, err)
+
+	messages = append(messages, Message{Id: "quotes", Message: "Ele disse \"não\"\ne saiu"})
+
+	for _, message := range messages {
+		require.NoError(t, sender.SendMessage(t.Context(), message), message.Id)
+
+		var payload any
+		require.NoError(t, json.Unmarshal(delivered, &payload), message.Id)
+		assert.True(t, containsText(payload, message.Message), message.Id)
+	}
+}
+
+func TestGoogleChatSenderEscapesBrokenMessage(t *testing.T) {
+	sender, err := NewHardcodedGoogleChatProvider("", mustGoogleChatTemplates(t), GoogleChatThreadsConfig{}, LimitModeTruncate)
+	require.NoError(t, err)
+
+	payload, err := sender.Render(t.Context(), BrokenMessage{Name: "D'Ávila", Motive: "Deu \"push --force\"\nna main"})
+	require.NoError(t, err)
+
+	var decoded any
+	require.NoError(t, json.Unmarshal(payload, &decoded))
+	assert.True(t, containsText(decoded, "D'Ávila"))
+	assert.True(t, containsText(decoded, "Deu \"push --force\"\nna main"))
+}
+
+func TestGoogleChatSenderMentionsTeamMembers(t *testing.T) {
+	sender, err := NewHardcodedGoogleChatProvider("", mustGoogleChatTemplates(t), GoogleChatThreadsConfig{}, LimitModeTruncate)
+	require.NoError(t, err)
+
+	ctx := WithMentions(t.Context(), TeamMember{Name: "Ana", GoogleChatID: "users/123"}, TeamMember{Name: "Bruno"})
+
+	payload, err := sender.Render(ctx, BrokenMessage{Name: "Ana", Motive: "push --force"})
+	require.NoError(t, err)
+
+	var decoded struct {
+		Text    string `json:"text"`
+		CardsV2 []any  `json:"cardsV2"`
+	}
+	require.NoError(t, json.Unmarshal(payload, &decoded))
+	assert.Equal(t, "<users/123>", decoded.Text)
+	assert.NotEmpty(t, decoded.CardsV2)
+}
+
+func TestGoogleChatSenderThreads(t *testing.T) {
+	var query url.Values
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		query = r.URL.Query()
+	}))
+	defer srv.Close()
+
+	sender, err := NewHardcodedGoogleChatProvider(srv.URL+"?key=k", mustGoogleChatTemplates(t), GoogleChatThreadsConfig{
+		Message:     ThreadStrategyStanding,
+		Broken:      ThreadStrategyPerson,
+		Digest:      ThreadStrategyDaily,
+		StandingKey: "wilson",
+	}, LimitModeTruncate)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name      string
+		send      func() error
+		threadKey string
+	}{
+		{
+			name:      "standing",
+			send:      func() error { return sender.SendMessage(t.Context(), Message{Message: "Obrigado"}) },
+			threadKey: "wilson",
+		},
+		{
+			name:      "person",
+			send:      func() error { return sender.SendBrokenMessage(t.Context(), BrokenMessage{Name: " Wilson "}) },
+			threadKey: "wilson-broken-wilson",
+		},
+		{
+			name:      "daily",
+			send:      func() error { return sender.SendDigest(t.Context(), Digest{}) },
+			threadKey: "wilson-digest-" + time.Now().Format(time.DateOnly),
+		},
+		{
+			name: "none",
+			send: func() error { return sender.SendAlert(t.Context(), AlertNotification{}) },
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			require.NoError(t, tt.send())
+
+			assert.Equal(t, "k", query.Get("key"))
+			assert.Equal(t, tt.threadKey, query.Get("threadKey"))
+			if tt.threadKey != "" {
+				assert.Equal(t, "REPLY_MESSAGE_FALLBACK_TO_NEW_THREAD", query.Get("messageReplyOption"))
+			} else {
+				assert.False(t, query.Has("messageReplyOption"))
+			}
+		})
+	}
+}
+
+func TestGoogleChatSenderRejectsInvalidThreads(t *testing.T) {
+	configs := []GoogleChatThreadsConfig{
+		{Message: "weekly"},
+		{Message: ThreadStrategyPerson},
+		{Alert: ThreadStrategyStanding},
+	}
+
+	for _, cfg := range configs {
+		_, err := NewHardcodedGoogleChatProvider("", mustGoogleChatTemplates(t), cfg, LimitModeTruncate)
+		assert.ErrorIs(t, err, ErrInvalidThreadStrategy)
+	}
+}
+
+func TestGoogleChatSenderSplitsLongMotive(t *testing.T) {
+	var (
+		delivered [][]byte
+		queries   []url.Values
+	)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		delivered = append(delivered, body)
+		queries = append(queries, r.URL.Query())
+
+		fmt.Fprintf(w, `{"name":"spaces/s/messages/%d","thread":{"name":"spaces/s/threads/t"}}`, len(delivered))
+	}))
+	defer srv.Close()
+
+	sender, err := NewHardcodedGoogleChatProvider(srv.URL, mustGoogleChatTemplates(t), GoogleChatThreadsConfig{}, LimitModeSplit)
+	require.NoError(t, err)
+
+	ctx, posted := WithPostedMessages(t.Context())
+
+	motive := strings.Repeat("a", googleChatTextLimit) + " fim"
+	require.NoError(t, sender.SendBrokenMessage(ctx, BrokenMessage{Name: "Wilson", Motive: motive}))
+
+	require.Len(t, delivered, 2)
+	assert.JSONEq(t, `{"text": "fim", "thread": {"name": "spaces/s/threads/t"}}`, string(delivered[1]))
+	assert.Equal(t, "REPLY_MESSAGE_FALLBACK_TO_NEW_THREAD", queries[1].Get("messageReplyOption"))
+	assert.Equal(t, []string{"spaces/s/messages/1", "spaces/s/messages/2"}, posted.IDs()[googleChatPlatform])
+}
@@ -0,0 +1,79 @@
+package internal
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ThreadStrategy decides which Google Chat thread a kind of card goes to
+type ThreadStrategy string
+
+const (
+	// ThreadStrategyNone starts a new thread for every card
+	ThreadStrategyNone ThreadStrategy = ""
+	// ThreadStrategyStanding replies into the same thread forever
+	ThreadStrategyStanding ThreadStrategy = "standing"
+	// ThreadStrategyDaily groups the cards of the same day
+	ThreadStrategyDaily ThreadStrategy = "daily"
+	// ThreadStrategyPerson groups the breakages of the same person
+	ThreadStrategyPerson ThreadStrategy = "person"
+)
+
+// replyOption keeps the card from failing when the thread can't be found
+const replyOption = "REPLY_MESSAGE_FALLBACK_TO_NEW_THREAD"
+
+var (
+	ErrInvalidThreadStrategy = errors.New("invalid thread strategy")
+)
+
+type googleChatThreads struct {
+	strategies  map[string]ThreadStrategy
+	standingKey string
+}
+
+func newGoogleChatThreads(cfg GoogleChatThreadsConfig) (*googleChatThreads, error) {
+	strategies := map[string]ThreadStrategy{
+		SendKindMessage: cfg.Message,
+		SendKindBroken:  cfg.Broken,
+		SendKindDigest:  cfg.Digest,
+		SendKindAlert:   cfg.Alert,
+	}
+
+	for kind, strategy := range strategies {
+		switch strategy {
+		case ThreadStrategyNone, ThreadStrategyDaily:
+		case ThreadStrategyStanding:
+			if cfg.StandingKey == "" {
+				return nil, fmt.Errorf("%w: %s needs a standing_key", ErrInvalidThreadStrategy, kind)
+			}
+		case ThreadStrategyPerson:
+			if kind != SendKindBroken {
+				return nil, fmt.Errorf("%w: only broken messages have a person", ErrInvalidThreadStrategy)
+			}
+		default:
+			return nil, fmt.Errorf("%w: %q", ErrInvalidThreadStrategy, strategy)
+		}
+	}
+
+	return &googleChatThreads{
+		strategies:  strategies,
+		standingKey: cfg.StandingKey,
+	}, nil
+}
+
+// threadKey returns the key of the thread the card of the kind replies to,
+// empty when it starts its own
+func (t *googleChatThreads) threadKey(kind string, person string, now time.Time) string {
+	switch t.strategies[kind] {
+	case ThreadStrategyStanding:
+		return t.standingKey
+	case ThreadStrategyDaily:
+		return "wilson-" + kind + "-" + now.Format(time.DateOnly)
+	case ThreadStrategyPerson:
+		return "wilson-" + kind + "-" + strings.ToLower(strings.TrimSpace(person))
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,203 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+const readinessTimeout = 5 * time.Second
+
+var (
+	ErrEmptyWebhookURL = errors.New("webhook url is empty")
+	ErrNotRunning      = errors.New("not running")
+)
+
+// HealthChecker is implemented by the components that can tell if they are
+// able to do their job
+type HealthChecker interface {
+	CheckHealth(ctx context.Context) error
+}
+
+// HealthCheckFunc adapts a function into a HealthChecker
+type HealthCheckFunc func(ctx context.Context) error
+
+func (f HealthCheckFunc) CheckHealth(ctx context.Context) error {
+	return f(ctx)
+}
+
+type readinessCheck struct {
+	name    string
+	checker HealthChecker
+}
+
+type ComponentStatus struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type ReadinessReport struct {
+	Status     string                     `json:"status"`
+	Components map[string]ComponentStatus `json:"components"`
+}
+
+// AddReadinessCheck makes /readyz fail while the component is unhealthy
+func (s *Server) AddReadinessCheck(name string, checker HealthChecker) {
+	s.readinessChecks = append(s.readinessChecks, readinessCheck{name: name, checker: checker})
+}
+
+func (s *Server) Livez(c echo.Context) error {
+	return c.JSON(200, map[string]string{"status": "ok"})
+}
+
+// Readyz runs every readiness check concurrently and reports each component
+func (s *Server) Readyz(c echo.Context) error {
+	ctx, cancel := context.WithTimeout(c.Request().Context(), readinessTimeout)
+	defer cancel()
+
+	report := ReadinessReport{
+		Status:     "ok",
+		Components: make(map[string]ComponentStatus, len(s.readinessChecks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, check := range s.readinessChecks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+
+			status := ComponentStatus{Status: "ok"}
+			if err := check.checker.CheckHealth(ctx); err != nil {
+				status = ComponentStatus{Status: "unavailable", Error: err.Error()}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+
+			report.Components[check.name] = status
+			if status.Status != "ok" {
+				report.Status = "unavailable"
+			}
+		}()
+	}
+
+	wg.Wait()
+
+	if report.Status != "ok" {
+		return c.JSON(503, report)
+	}
+
+	return c.JSON(200, report)
+}
+
+// CheckMessageStorer fails if the storer can't be reached or has no messages
+func CheckMessageStorer(storer MessageStorer) HealthChecker {
+	return HealthCheckFunc(func(ctx context.Context) error {
+		messages, err := storer.GetAllMessages(ctx)
+		if err != nil {
+			return err
+		}
+
+		if len(messages) == 0 {
+			return errors.New("no messages loaded")
+		}
+
+		return nil
+	})
+}
+
+// ValidateWebhookURL checks that the webhook url is an absolute http(s) url
+func ValidateWebhookURL(rawURL string) error {
+	if rawURL == "" {
+		return ErrEmptyWebhookURL
+	}
+
+	// the url carries the webhook token, keep it out of the errors
+	if !isAbsoluteHTTPURL(rawURL) {
+		return errors.New("webhook url is not an absolute http url")
+	}
+
+	return nil
+}
+
+func isAbsoluteHTTPURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+
+	return err == nil && (u.Scheme == "https" || u.Scheme == "http") && u.Host != ""
+}
+
+// WebhookProbe checks that the webhook url answers, caching the result so
+// readiness polling doesn't hammer the chat platforms
+type WebhookProbe struct {
+	webhookURL string
+	ttl        time.Duration
+	httpClient *http.Client
+
+	mu        sync.Mutex
+	checkedAt time.Time
+	lastErr   error
+}
+
+var (
+	_ HealthChecker = (*WebhookProbe)(nil)
+)
+
+func NewWebhookProbe(webhookURL string, ttl time.Duration) *WebhookProbe {
+	return &WebhookProbe{
+		webhookURL: webhookURL,
+		ttl:        ttl,
+		httpClient: NewTracedHTTPClient(readinessTimeout),
+	}
+}
+
+func (p *WebhookProbe) CheckHealth(ctx context.Context) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if !p.checkedAt.IsZero() && time.Since(p.checkedAt) < p.ttl {
+		return p.lastErr
+	}
+
+	p.lastErr = p.probe(ctx)
+	p.checkedAt = time.Now()
+
+	return p.lastErr
+}
+
+// probe only fails on network errors, server errors and not found, not every
+// platform answers a GET on the webhook so anything else means it is alive
+func (p *WebhookProbe) probe(ctx context.Context) error {
+	if err := ValidateWebhookURL(p.webhookURL); err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.webhookURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		var urlErr *url.Error
+		if errors.As(err, &urlErr) {
+			err = urlErr.Err
+		}
+
+		return fmt.Errorf("webhook unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound || resp.StatusCode >= 500 {
+		return fmt.Errorf("webhook answered with status %d", resp.StatusCode)
+	}
+
+	return nil
+}
@@ -0,0 +1,83 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadyzReportsEachComponent(t *testing.T) {
+	e := echo.New()
+	mockStore := NewMockMessageStorer(t)
+	mockStore.On("GetAllMessages", mock.Anything).Return([]Message{{Id: "1"}}, nil)
+
+	server := &Server{echoServer: e}
+	server.AddReadinessCheck("message_storer", CheckMessageStorer(mockStore))
+	server.AddReadinessCheck("discord_webhook", HealthCheckFunc(func(ctx context.Context) error {
+		return ValidateWebhookURL("")
+	}))
+
+	rec := httptest.NewRecorder()
+	err := server.Readyz(e.NewContext(httptest.NewRequest(http.MethodGet, "/readyz", nil), rec))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+
+	var report ReadinessReport
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &report))
+	assert.Equal(t, "unavailable", report.Status)
+	assert.Equal(t, "ok", report.Components["message_storer"].Status)
+	assert.Equal(t, ErrEmptyWebhookURL.Error(), report.Components["discord_webhook"].Error)
+}
+
+func TestReadyzOk(t *testing.T) {
+	e := echo.New()
+	server := &Server{echoServer: e}
+	server.AddReadinessCheck("noop", HealthCheckFunc(func(ctx context.Context) error { return nil }))
+
+	rec := httptest.NewRecorder()
+	err := server.Readyz(e.NewContext(httptest.NewRequest(http.MethodGet, "/readyz", nil), rec))
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestValidateWebhookURL(t *testing.T) {
+	assert.NoError(t, ValidateWebhookURL("https://discord.com/api/webhooks/1/token"))
+	assert.ErrorIs(t, ValidateWebhookURL(""), ErrEmptyWebhookURL)
+	assert.Error(t, ValidateWebhookURL("discord.com/api/webhooks/1/token"))
+	assert.Error(t, ValidateWebhookURL("ftp://discord.com"))
+}
+
+func TestWebhookProbeCachesResult(t *testing.T) {
+	calls := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer srv.Close()
+
+	probe := NewWebhookProbe(srv.URL, time.Minute)
+
+	assert.Error(t, probe.CheckHealth(t.Context()))
+	assert.Error(t, probe.CheckHealth(t.Context()))
+	assert.Equal(t, 1, calls)
+}
+
+func TestCheckMessageStorerFails(t *testing.T) {
+	mockStore := NewMockMessageStorer(t)
+	mockStore.On("GetAllMessages", mock.Anything).Return(nil, errors.New("connection refused")).Once()
+	mockStore.On("GetAllMessages", mock.Anything).Return([]Message{}, nil).Once()
+
+	checker := CheckMessageStorer(mockStore)
+
+	assert.EqualError(t, checker.CheckHealth(t.Context()), "connection refused")
+	assert.Error(t, checker.CheckHealth(t.Context()))
+}
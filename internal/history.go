@@ -0,0 +1,627 @@
+package internal
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+const (
+	SendKindMessage = "message"
+	SendKindBroken  = "broken"
+	SendKindDigest  = "digest"
+	SendKindAlert   = "alert"
+)
+
+var (
+	ErrSendRecordNotFound = errors.New("send record not found")
+)
+
+type SendRecord struct {
+	Id        string    `json:"id"`
+	Kind      string    `json:"kind"`
+	MessageID string    `json:"message_id"`
+	Tags      []string  `json:"tags"`
+	SentAt    time.Time `json:"sent_at"`
+
+	// DryRunPayloads holds the payloads rendered by the senders in dry run
+	DryRunPayloads map[string]json.RawMessage `json:"dry_run_payloads,omitempty"`
+
+	// PostedMessageIDs holds the ids of the posted messages by platform, the
+	// card first and then its follow ups
+	PostedMessageIDs map[string][]string `json:"posted_message_ids,omitempty"`
+	// ImageURL, Vars and Target are what the message was sent with, the
+	// edits are rendered with them again
+	ImageURL string            `json:"image_url,omitempty"`
+	Vars     map[string]string `json:"vars,omitempty"`
+	Target   string            `json:"target,omitempty"`
+
+	// Error is set when the send failed after some platform posted
+	Error     string     `json:"error,omitempty"`
+	EditedAt  *time.Time `json:"edited_at,omitempty"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
+}
+
+// clone copies the record along with its maps and slices, so the copy can be
+// changed outside the storer
+func (r SendRecord) clone() SendRecord {
+	r.Tags = slices.Clone(r.Tags)
+	r.DryRunPayloads = maps.Clone(r.DryRunPayloads)
+	r.Vars = maps.Clone(r.Vars)
+
+	if r.PostedMessageIDs != nil {
+		posted := make(map[string][]string, len(r.PostedMessageIDs))
+		for platform, messageIDs := range r.PostedMessageIDs {
+			posted[platform] = slices.Clone(messageIDs)
+		}
+		r.PostedMessageIDs = posted
+	}
+
+	return r
+}
+
+type HistoryStorer interface {
+	AddSendRecord(ctx context.Context, record SendRecord) error
+	GetSendRecords(ctx context.Context, since time.Time, until time.Time) ([]SendRecord, error)
+	GetSendRecordByID(ctx context.Context, id string) (*SendRecord, error)
+	// UpdateSendRecord applies update to the record atomically, nothing is
+	// changed when update fails
+	UpdateSendRecord(ctx context.Context, id string, update func(record *SendRecord) error) (*SendRecord, error)
+}
+
+type InMemoryHistoryStorer struct {
+	mu      sync.RWMutex
+	records []SendRecord
+}
+
+var (
+	_ HistoryStorer = (*InMemoryHistoryStorer)(nil)
+)
+
+func NewInMemoryHistoryStorer() *InMemoryHistoryStorer {
+	return &InMemoryHistoryStorer{}
+}
+
+func (s *InMemoryHistoryStorer) AddSendRecord(ctx context.Context, record SendRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.records = append(s.records, record)
+
+	return nil
+}
+
+// GetSendRecords returns the records sent in the [since, until) interval
+func (s *InMemoryHistoryStorer) GetSendRecords(ctx context.Context, since time.Time, until time.Time) ([]SendRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var records []SendRecord
+	for _, r := range s.records {
+		if r.SentAt.Before(since) || !r.SentAt.Before(until) {
+			continue
+		}
+		records = append(records, r.clone())
+	}
+
+	return records, nil
+}
+
+func (s *InMemoryHistoryStorer) GetSendRecordByID(ctx context.Context, id string) (*SendRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, r := range s.records {
+		if r.Id == id {
+			record := r.clone()
+			return &record, nil
+		}
+	}
+
+	return nil, ErrSendRecordNotFound
+}
+
+func (s *InMemoryHistoryStorer) UpdateSendRecord(ctx context.Context, id string, update func(record *SendRecord) error) (*SendRecord, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.records, func(record SendRecord) bool { return record.Id == id })
+	if i < 0 {
+		return nil, ErrSendRecordNotFound
+	}
+
+	// updated on a copy, the stored record is only replaced when update works
+	record := s.records[i].clone()
+
+	err := update(&record)
+	if err != nil {
+		return nil, err
+	}
+
+	s.records[i] = record
+
+	return &record, nil
+}
+
+// PostedMessages collects the ids of the messages posted while handling a
+// single send, along with the image picked for the card
+type PostedMessages struct {
+	mu       sync.Mutex
+	ids      map[string][]string
+	imageURL string
+}
+
+// IDs returns the posted message ids by platform, in the order they were
+// posted
+func (p *PostedMessages) IDs() map[string][]string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	if p.ids == nil {
+		return nil
+	}
+
+	ids := make(map[string][]string, len(p.ids))
+	for platform, messageIDs := range p.ids {
+		ids[platform] = slices.Clone(messageIDs)
+	}
+
+	return ids
+}
+
+// ImageURL returns the image picked for the card
+func (p *PostedMessages) ImageURL() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	return p.imageURL
+}
+
+type postedMessagesKey struct{}
+
+// WithPostedMessages attaches a collector to the context, reusing the one
+// already attached
+func WithPostedMessages(ctx context.Context) (context.Context, *PostedMessages) {
+	if posted, ok := ctx.Value(postedMessagesKey{}).(*PostedMessages); ok {
+		return ctx, posted
+	}
+
+	posted := &PostedMessages{}
+
+	return context.WithValue(ctx, postedMessagesKey{}, posted), posted
+}
+
+// RecordPostedMessage is called by the senders for every message the platform
+// answers with an id, the card and its follow ups
+func RecordPostedMessage(ctx context.Context, platform string, messageID string) {
+	posted, ok := ctx.Value(postedMessagesKey{}).(*PostedMessages)
+	if !ok {
+		return
+	}
+
+	posted.mu.Lock()
+	defer posted.mu.Unlock()
+
+	if posted.ids == nil {
+		posted.ids = make(map[string][]string)
+	}
+
+	posted.ids[platform] = append(posted.ids[platform], messageID)
+}
+
+// RecordCardImage keeps the image picked for the card, so the edits of the
+// message show the same one
+func RecordCardImage(ctx context.Context, imageURL string) {
+	posted, ok := ctx.Value(postedMessagesKey{}).(*PostedMessages)
+	if !ok {
+		return
+	}
+
+	posted.mu.Lock()
+	defer posted.mu.Unlock()
+
+	posted.imageURL = imageURL
+}
+
+// RecordingMessageSender decorates a MessageSender saving every successful
+// send into the history and every broken message into the breakage tracker
+type RecordingMessageSender struct {
+	next            MessageSender
+	history         HistoryStorer
+	breakageTracker BreakageTracker
+	rosterStorer    RosterStorer
+}
+
+var (
+	_ MessageSender = (*RecordingMessageSender)(nil)
+)
+
+func NewRecordingMessageSender(
+	next MessageSender,
+	history HistoryStorer,
+	breakageTracker BreakageTracker,
+	rosterStorer RosterStorer,
+) *RecordingMessageSender {
+	return &RecordingMessageSender{
+		next:            next,
+		history:         history,
+		breakageTracker: breakageTracker,
+		rosterStorer:    rosterStorer,
+	}
+}
+
+func (r *RecordingMessageSender) SendMessage(ctx context.Context, message Message) error {
+	ctx, payloads := WithDryRunPayloads(ctx)
+	ctx, posted := WithPostedMessages(ctx)
+
+	err := r.next.SendMessage(ctx, message)
+
+	r.addSendRecord(ctx, SendRecord{
+		Kind:             SendKindMessage,
+		MessageID:        message.Id,
+		Tags:             message.Tags,
+		DryRunPayloads:   payloads.Payloads(),
+		PostedMessageIDs: posted.IDs(),
+		ImageURL:         posted.ImageURL(),
+		Vars:             messageVars(ctx),
+		Target:           messageTarget(ctx),
+	}, err)
+
+	return err
+}
+
+// SendBrokenMessage records the breakage even if the delivery fails, the build
+// is still broken after all
+func (r *RecordingMessageSender) SendBrokenMessage(ctx context.Context, message BrokenMessage) error {
+	ctx, payloads := WithDryRunPayloads(ctx)
+	ctx, posted := WithPostedMessages(ctx)
+
+	err := r.breakageTracker.RecordBreakage(ctx, Breakage{
+		Id:       message.Id,
+		Name:     message.Name,
+		Motive:   message.Motive,
+		BrokenAt: time.Now(),
+	})
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to record breakage", slog.Any("error", err))
+	} else {
+		breakagesTotal.WithLabelValues(r.breakageMember(ctx, message.Name)).Inc()
+	}
+
+	err = r.next.SendBrokenMessage(ctx, message)
+
+	r.addSendRecord(ctx, SendRecord{
+		Kind:             SendKindBroken,
+		MessageID:        message.Id,
+		DryRunPayloads:   payloads.Payloads(),
+		PostedMessageIDs: posted.IDs(),
+	}, err)
+
+	return err
+}
+
+// breakageMember labels the breakage with the roster member, anyone else
+// goes to a single bucket so the names sent to the webhooks can't grow the
+// metric
+func (r *RecordingMessageSender) breakageMember(ctx context.Context, name string) string {
+	if r.rosterStorer == nil {
+		return unknownMember
+	}
+
+	member, err := FindTeamMember(ctx, r.rosterStorer, name)
+	if err != nil {
+		return unknownMember
+	}
+
+	return member.Id
+}
+
+func (r *RecordingMessageSender) SendDigest(ctx context.Context, digest Digest) error {
+	ctx, payloads := WithDryRunPayloads(ctx)
+	ctx, posted := WithPostedMessages(ctx)
+
+	err := r.next.SendDigest(ctx, digest)
+
+	r.addSendRecord(ctx, SendRecord{
+		Kind:             SendKindDigest,
+		MessageID:        digest.Id,
+		DryRunPayloads:   payloads.Payloads(),
+		PostedMessageIDs: posted.IDs(),
+	}, err)
+
+	return err
+}
+
+func (r *RecordingMessageSender) SendAlert(ctx context.Context, notification AlertNotification) error {
+	ctx, payloads := WithDryRunPayloads(ctx)
+	ctx, posted := WithPostedMessages(ctx)
+
+	err := r.next.SendAlert(ctx, notification)
+
+	r.addSendRecord(ctx, SendRecord{
+		Kind:             SendKindAlert,
+		MessageID:        notification.Id,
+		DryRunPayloads:   payloads.Payloads(),
+		PostedMessageIDs: posted.IDs(),
+	}, err)
+
+	return err
+}
+
+// addSendRecord skips the failed sends, unless some platform posted before
+// the failure as that message can still be edited or deleted
+func (r *RecordingMessageSender) addSendRecord(ctx context.Context, record SendRecord, sendErr error) {
+	if sendErr != nil {
+		if len(record.PostedMessageIDs) == 0 {
+			return
+		}
+
+		record.Error = sendErr.Error()
+	}
+
+	record.Id = uuid.NewString()
+	record.SentAt = time.Now()
+
+	err := r.history.AddSendRecord(ctx, record)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to add send record", slog.Any("error", err))
+	}
+}
+
+// MessageEditor is implemented by the senders able to change a message after
+// it was posted
+type MessageEditor interface {
+	EditMessage(ctx context.Context, messageID string, message Message) error
+	DeleteMessage(ctx context.Context, messageID string) error
+}
+
+// SetMessagePipeline sets the decorators the messages go through before the
+// platform senders, the previews and the edits go through them as well
+func (s *Server) SetMessagePipeline(pipeline func(next MessageSender) MessageSender) {
+	s.pipeline = pipeline
+}
+
+// throughPipeline decorates the sender with the message pipeline, if any
+func (s *Server) throughPipeline(next MessageSender) MessageSender {
+	if s.pipeline == nil {
+		return next
+	}
+
+	return s.pipeline(next)
+}
+
+// editingMessageSender ends the message pipeline editing a posted message
+// instead of sending a new one
+type editingMessageSender struct {
+	editor    MessageEditor
+	messageID string
+}
+
+var (
+	_ MessageSender = (*editingMessageSender)(nil)
+)
+
+func (s *editingMessageSender) SendMessage(ctx context.Context, message Message) error {
+	return s.editor.EditMessage(ctx, s.messageID, message)
+}
+
+func (s *editingMessageSender) SendBrokenMessage(ctx context.Context, message BrokenMessage) error {
+	return ErrUnsupportedContent
+}
+
+func (s *editingMessageSender) SendDigest(ctx context.Context, digest Digest) error {
+	return ErrUnsupportedContent
+}
+
+func (s *editingMessageSender) SendAlert(ctx context.Context, notification AlertNotification) error {
+	return ErrUnsupportedContent
+}
+
+// AddMessageEditor lets the history endpoints edit and delete the messages
+// posted to the platform
+func (s *Server) AddMessageEditor(platform string, editor MessageEditor) {
+	if s.editors == nil {
+		s.editors = make(map[string]MessageEditor)
+	}
+
+	s.editors[platform] = editor
+}
+
+type editSendRecordRequest struct {
+	Message  string `json:"message"`
+	ImageURL string `json:"image_url"`
+}
+
+// GetSendRecords lists the records sent in the since and until RFC 3339 query
+// interval, the last day by default
+func (s *Server) GetSendRecords(c echo.Context) error {
+	until := time.Now()
+	since := until.AddDate(0, 0, -1)
+
+	for param, value := range map[string]*time.Time{"since": &since, "until": &until} {
+		raw := c.QueryParam(param)
+		if raw == "" {
+			continue
+		}
+
+		parsed, err := time.Parse(time.RFC3339, raw)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": param + " must be an RFC 3339 timestamp"})
+		}
+
+		*value = parsed
+	}
+
+	records, err := s.historyStorer.GetSendRecords(c.Request().Context(), since, until)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(200, records)
+}
+
+// EditSendRecord replaces the text of a posted message in every platform
+// that can edit it
+func (s *Server) EditSendRecord(c echo.Context) error {
+	var req editSendRecordRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(400, map[string]string{"error": "invalid request"})
+	}
+
+	if req.Message == "" {
+		return c.JSON(400, map[string]string{"error": "message is required"})
+	}
+
+	ctx := c.Request().Context()
+
+	record, code, err := s.editableSendRecord(ctx, c.Param("id"))
+	if err != nil {
+		return c.JSON(code, map[string]string{"error": err.Error()})
+	}
+
+	if record.Kind != SendKindMessage {
+		return c.JSON(400, map[string]string{"error": "only messages can be edited"})
+	}
+
+	// rendered again with the image, vars and target of the send
+	message := Message{
+		Id:       record.MessageID,
+		Message:  req.Message,
+		Tags:     record.Tags,
+		ImageURL: cmp.Or(req.ImageURL, record.ImageURL),
+	}
+
+	ctx = WithMessageTarget(WithMessageVars(ctx, record.Vars), record.Target)
+
+	// the edited card is truncated, its old follow ups would only confuse
+	remaining, err := s.forEachPostedMessage(ctx, record, func(editor MessageEditor, messageIDs []string) ([]string, error) {
+		sender := s.throughPipeline(&editingMessageSender{editor: editor, messageID: messageIDs[0]})
+
+		err := sender.SendMessage(ctx, message)
+		if err != nil {
+			return messageIDs, err
+		}
+
+		return messageIDs[:1], deleteMessages(ctx, editor, messageIDs[1:])
+	})
+	if errors.Is(err, ErrMissingVariable) {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	now := time.Now()
+	record, err = s.historyStorer.UpdateSendRecord(ctx, record.Id, func(record *SendRecord) error {
+		maps.Copy(record.PostedMessageIDs, remaining)
+		record.EditedAt = &now
+		record.ImageURL = message.ImageURL
+		return nil
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(200, record)
+}
+
+// DeleteSendRecord retracts a posted message from every platform that can
+// delete it, the record is kept and marked as deleted
+func (s *Server) DeleteSendRecord(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	record, code, err := s.editableSendRecord(ctx, c.Param("id"))
+	if err != nil {
+		return c.JSON(code, map[string]string{"error": err.Error()})
+	}
+
+	_, err = s.forEachPostedMessage(ctx, record, func(editor MessageEditor, messageIDs []string) ([]string, error) {
+		return messageIDs, deleteMessages(ctx, editor, messageIDs)
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	now := time.Now()
+	_, err = s.historyStorer.UpdateSendRecord(ctx, record.Id, func(record *SendRecord) error {
+		record.DeletedAt = &now
+		return nil
+	})
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	return c.NoContent(204)
+}
+
+// editableSendRecord returns the record along with the status code to answer
+// when it can't be changed
+func (s *Server) editableSendRecord(ctx context.Context, id string) (*SendRecord, int, error) {
+	record, err := s.historyStorer.GetSendRecordByID(ctx, id)
+	if errors.Is(err, ErrSendRecordNotFound) {
+		return nil, 404, err
+	}
+	if err != nil {
+		return nil, 500, err
+	}
+
+	if record.DeletedAt != nil {
+		return nil, 409, errors.New("message already deleted")
+	}
+
+	for platform := range record.PostedMessageIDs {
+		if _, ok := s.editors[platform]; ok {
+			return record, 0, nil
+		}
+	}
+
+	return nil, 409, errors.New("message wasn't posted to a platform that can change it")
+}
+
+// forEachPostedMessage calls fn with the messages posted to every platform
+// that can change them, fn returns the ids left in the record. The ids left
+// by platform are returned for the caller to store
+func (s *Server) forEachPostedMessage(
+	ctx context.Context,
+	record *SendRecord,
+	fn func(editor MessageEditor, messageIDs []string) ([]string, error),
+) (map[string][]string, error) {
+	remaining := make(map[string][]string)
+
+	var errs []error
+	for platform, messageIDs := range record.PostedMessageIDs {
+		editor, ok := s.editors[platform]
+		if !ok {
+			slog.WarnContext(ctx, "platform can't change posted messages", slog.String("platform", platform))
+			continue
+		}
+
+		if len(messageIDs) == 0 {
+			continue
+		}
+
+		var err error
+		remaining[platform], err = fn(editor, messageIDs)
+		errs = append(errs, err)
+	}
+
+	return remaining, errors.Join(errs...)
+}
+
+func deleteMessages(ctx context.Context, editor MessageEditor, messageIDs []string) error {
+	var errs []error
+	for _, messageID := range messageIDs {
+		errs = append(errs, editor.DeleteMessage(ctx, messageID))
+	}
+
+	return errors.Join(errs...)
+}
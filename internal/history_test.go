@@ -0,0 +1,267 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecordingMessageSenderKeepsPostedMessageIDs(t *testing.T) {
+	history := NewInMemoryHistoryStorer()
+
+	mockSender := NewMockMessageSender(t)
+	mockSender.On("SendMessage", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			RecordPostedMessage(args.Get(0).(context.Context), "discord", "123")
+		}).
+		Return(nil)
+
+	sender := NewRecordingMessageSender(mockSender, history, NewInMemoryBreakageTracker(), nil)
+	require.NoError(t, sender.SendMessage(t.Context(), Message{Id: "1"}))
+
+	records, err := history.GetSendRecords(t.Context(), time.Time{}, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, map[string][]string{"discord": {"123"}}, records[0].PostedMessageIDs)
+}
+
+func TestRecordingMessageSenderKeepsPostedMessageIDsOnFailure(t *testing.T) {
+	history := NewInMemoryHistoryStorer()
+
+	mockSender := NewMockMessageSender(t)
+	mockSender.On("SendAlert", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			RecordPostedMessage(args.Get(0).(context.Context), "discord", "123")
+		}).
+		Return(assert.AnError)
+
+	sender := NewRecordingMessageSender(mockSender, history, NewInMemoryBreakageTracker(), nil)
+	require.ErrorIs(t, sender.SendAlert(t.Context(), AlertNotification{Id: "1"}), assert.AnError)
+
+	records, err := history.GetSendRecords(t.Context(), time.Time{}, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, map[string][]string{"discord": {"123"}}, records[0].PostedMessageIDs)
+	assert.Equal(t, assert.AnError.Error(), records[0].Error)
+}
+
+func TestInMemoryHistoryStorerReturnsCopies(t *testing.T) {
+	history := NewInMemoryHistoryStorer()
+	require.NoError(t, history.AddSendRecord(t.Context(), SendRecord{Id: "1", PostedMessageIDs: map[string][]string{"discord": {"123", "124"}}}))
+
+	record, err := history.GetSendRecordByID(t.Context(), "1")
+	require.NoError(t, err)
+	record.PostedMessageIDs["discord"] = nil
+
+	_, err = history.UpdateSendRecord(t.Context(), "1", func(record *SendRecord) error {
+		record.PostedMessageIDs["discord"] = record.PostedMessageIDs["discord"][:1]
+		return assert.AnError
+	})
+	require.ErrorIs(t, err, assert.AnError)
+
+	stored, err := history.GetSendRecordByID(t.Context(), "1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{"discord": {"123", "124"}}, stored.PostedMessageIDs)
+}
+
+func TestEditSendRecord(t *testing.T) {
+	tests := []struct {
+		name         string
+		record       SendRecord
+		body         string
+		edits        bool
+		expectedCode int
+	}{
+		{
+			name:         "edits the posted message",
+			record:       SendRecord{Id: "1", Kind: SendKindMessage, MessageID: "m", PostedMessageIDs: map[string][]string{"discord": {"123"}}},
+			body:         `{"message":"Desculpa"}`,
+			edits:        true,
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "missing message",
+			record:       SendRecord{Id: "1", Kind: SendKindMessage, PostedMessageIDs: map[string][]string{"discord": {"123"}}},
+			body:         `{}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "not a message",
+			record:       SendRecord{Id: "1", Kind: SendKindAlert, PostedMessageIDs: map[string][]string{"discord": {"123"}}},
+			body:         `{"message":"Desculpa"}`,
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "not posted to an editable platform",
+			record:       SendRecord{Id: "1", Kind: SendKindMessage, PostedMessageIDs: map[string][]string{"googlechat": {"123"}}},
+			body:         `{"message":"Desculpa"}`,
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:         "unknown record",
+			record:       SendRecord{Id: "2"},
+			body:         `{"message":"Desculpa"}`,
+			expectedCode: http.StatusNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history := NewInMemoryHistoryStorer()
+			require.NoError(t, history.AddSendRecord(t.Context(), tt.record))
+
+			editor := NewMockMessageEditor(t)
+			if tt.edits {
+				editor.On("EditMessage", mock.Anything, "123", Message{Id: "m", Message: "Desculpa"}).Return(nil)
+			}
+
+			e := echo.New()
+			server := &Server{historyStorer: history, echoServer: e}
+			server.AddMessageEditor("discord", editor)
+
+			req := httptest.NewRequest(http.MethodPatch, "/history/1", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues("1")
+
+			err := server.EditSendRecord(c)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rec.Code)
+
+			if tt.edits {
+				var record SendRecord
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &record))
+				assert.NotNil(t, record.EditedAt)
+			}
+		})
+	}
+}
+
+func TestEditSendRecordRendersLikeTheSend(t *testing.T) {
+	history := NewInMemoryHistoryStorer()
+	roster := NewInMemoryRosterStorer(TeamMember{Id: "ana", Name: "Ana", DiscordID: "1"})
+
+	mockSender := NewMockMessageSender(t)
+	mockSender.On("SendMessage", mock.Anything, mock.Anything).
+		Run(func(args mock.Arguments) {
+			RecordPostedMessage(args.Get(0).(context.Context), "discord", "123")
+		}).
+		Return(nil)
+
+	images := NewInMemoryImageStorer(Image{Id: "1", URL: "https://example.com/pepe.png"})
+	pipeline := func(next MessageSender) MessageSender {
+		return NewMentionMessageSender(
+			NewPlaceholderMessageSender(NewImageMessageSender(next, images), nil),
+			roster,
+		)
+	}
+
+	ctx := WithMessageTarget(WithMessageVars(t.Context(), map[string]string{"name": "Ana"}), "ana")
+	sender := NewRecordingMessageSender(pipeline(mockSender), history, NewInMemoryBreakageTracker(), nil)
+	require.NoError(t, sender.SendMessage(ctx, Message{Id: "m", Message: "Valeu {{name}}"}))
+
+	records, err := history.GetSendRecords(t.Context(), time.Time{}, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.Len(t, records, 1)
+	assert.Equal(t, "https://example.com/pepe.png", records[0].ImageURL)
+	assert.Equal(t, map[string]string{"name": "Ana"}, records[0].Vars)
+	assert.Equal(t, "ana", records[0].Target)
+
+	editor := NewMockMessageEditor(t)
+	editor.On("EditMessage",
+		mock.MatchedBy(func(ctx context.Context) bool { return len(Mentions(ctx)) == 1 }),
+		"123",
+		Message{Id: "m", Message: "Desculpa Ana", ImageURL: "https://example.com/pepe.png"},
+	).Return(nil)
+
+	e := echo.New()
+	server := &Server{historyStorer: history, echoServer: e}
+	server.AddMessageEditor("discord", editor)
+	server.SetMessagePipeline(pipeline)
+
+	req := httptest.NewRequest(http.MethodPatch, "/history/"+records[0].Id, strings.NewReader(`{"message":"Desculpa {{name}}"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues(records[0].Id)
+
+	require.NoError(t, server.EditSendRecord(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestEditSendRecordDropsFollowUps(t *testing.T) {
+	history := NewInMemoryHistoryStorer()
+	require.NoError(t, history.AddSendRecord(t.Context(), SendRecord{
+		Id:               "1",
+		Kind:             SendKindMessage,
+		MessageID:        "m",
+		PostedMessageIDs: map[string][]string{"discord": {"123", "124"}},
+	}))
+
+	editor := NewMockMessageEditor(t)
+	editor.On("EditMessage", mock.Anything, "123", mock.Anything).Return(nil)
+	editor.On("DeleteMessage", mock.Anything, "124").Return(nil)
+
+	e := echo.New()
+	server := &Server{historyStorer: history, echoServer: e}
+	server.AddMessageEditor("discord", editor)
+
+	req := httptest.NewRequest(http.MethodPatch, "/history/1", strings.NewReader(`{"message":"Desculpa"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+
+	require.NoError(t, server.EditSendRecord(c))
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	record, err := history.GetSendRecordByID(t.Context(), "1")
+	require.NoError(t, err)
+	assert.Equal(t, map[string][]string{"discord": {"123"}}, record.PostedMessageIDs)
+}
+
+func TestDeleteSendRecord(t *testing.T) {
+	history := NewInMemoryHistoryStorer()
+	require.NoError(t, history.AddSendRecord(t.Context(), SendRecord{
+		Id:               "1",
+		Kind:             SendKindAlert,
+		PostedMessageIDs: map[string][]string{"discord": {"123", "124"}},
+	}))
+
+	editor := NewMockMessageEditor(t)
+	editor.On("DeleteMessage", mock.Anything, "123").Return(nil).Once()
+	editor.On("DeleteMessage", mock.Anything, "124").Return(nil).Once()
+
+	e := echo.New()
+	server := &Server{historyStorer: history, echoServer: e}
+	server.AddMessageEditor("discord", editor)
+
+	for _, expectedCode := range []int{http.StatusNoContent, http.StatusConflict} {
+		req := httptest.NewRequest(http.MethodDelete, "/history/1", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		err := server.DeleteSendRecord(c)
+		require.NoError(t, err)
+		assert.Equal(t, expectedCode, rec.Code)
+	}
+
+	record, err := history.GetSendRecordByID(t.Context(), "1")
+	require.NoError(t, err)
+	assert.NotNil(t, record.DeletedAt)
+}
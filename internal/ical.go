@@ -0,0 +1,170 @@
+package internal
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+var (
+	ErrInvalidICal = errors.New("invalid icalendar file")
+)
+
+// ICalEvent is the part of a VEVENT the out of office import needs, the
+// dates are YYYY-MM-DD and both included
+type ICalEvent struct {
+	Summary   string
+	Start     string
+	End       string
+	Organizer string
+	Attendees []string
+}
+
+// ParseICal reads the events of an iCalendar (RFC 5545) file. Only the
+// summary, the dates and the organizer and attendee emails are kept, the
+// recurrences are not expanded
+func ParseICal(r io.Reader) ([]ICalEvent, error) {
+	lines, err := unfoldICalLines(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var (
+		events  []ICalEvent
+		event   *ICalEvent
+		endDate bool
+		began   bool
+	)
+
+	for _, line := range lines {
+		name, params, value, ok := parseICalLine(line)
+		if !ok {
+			continue
+		}
+
+		switch {
+		case name == "BEGIN" && value == "VCALENDAR":
+			began = true
+		case name == "BEGIN" && value == "VEVENT":
+			event = &ICalEvent{}
+			endDate = false
+		case name == "END" && value == "VEVENT":
+			if event == nil || event.Start == "" {
+				return nil, fmt.Errorf("%w: event without DTSTART", ErrInvalidICal)
+			}
+
+			if event.End == "" {
+				event.End = event.Start
+			}
+
+			// the end of an all day event is the day after the last one
+			if endDate && event.End > event.Start {
+				end, _ := time.Parse(time.DateOnly, event.End)
+				event.End = end.AddDate(0, 0, -1).Format(time.DateOnly)
+			}
+
+			events = append(events, *event)
+			event = nil
+		case event == nil:
+			continue
+		case name == "SUMMARY":
+			event.Summary = unescapeICalText(value)
+		case name == "DTSTART":
+			event.Start, _, err = parseICalDate(params, value)
+		case name == "DTEND":
+			event.End, endDate, err = parseICalDate(params, value)
+		case name == "ORGANIZER":
+			event.Organizer, _ = strings.CutPrefix(strings.ToLower(value), "mailto:")
+		case name == "ATTENDEE":
+			if email, ok := strings.CutPrefix(strings.ToLower(value), "mailto:"); ok {
+				event.Attendees = append(event.Attendees, email)
+			}
+		}
+
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if !began {
+		return nil, fmt.Errorf("%w: missing BEGIN:VCALENDAR", ErrInvalidICal)
+	}
+
+	return events, nil
+}
+
+// unfoldICalLines joins the lines continued on the next one, which start
+// with a space or a tab
+func unfoldICalLines(r io.Reader) ([]string, error) {
+	var lines []string
+
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+
+		lines = append(lines, line)
+	}
+
+	return lines, scanner.Err()
+}
+
+// parseICalLine splits NAME;PARAM=VALUE:value
+func parseICalLine(line string) (name string, params map[string]string, value string, ok bool) {
+	head, value, ok := strings.Cut(line, ":")
+	if !ok {
+		return "", nil, "", false
+	}
+
+	parts := strings.Split(head, ";")
+
+	params = make(map[string]string)
+	for _, param := range parts[1:] {
+		key, val, _ := strings.Cut(param, "=")
+		params[strings.ToUpper(key)] = strings.Trim(val, `"`)
+	}
+
+	return strings.ToUpper(parts[0]), params, value, true
+}
+
+// parseICalDate returns the day of a DATE or DATE-TIME value and whether it
+// was a DATE. The times are taken in their TZID, UTC or the local time
+func parseICalDate(params map[string]string, value string) (string, bool, error) {
+	if strings.EqualFold(params["VALUE"], "DATE") || len(value) == len("20060102") {
+		day, err := time.Parse("20060102", value)
+		if err != nil {
+			return "", false, fmt.Errorf("%w: invalid date %q", ErrInvalidICal, value)
+		}
+
+		return day.Format(time.DateOnly), true, nil
+	}
+
+	location := time.Local
+	if tzid := params["TZID"]; tzid != "" {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			location = l
+		}
+	}
+
+	if utc, ok := strings.CutSuffix(value, "Z"); ok {
+		value, location = utc, time.UTC
+	}
+
+	at, err := time.ParseInLocation("20060102T150405", value, location)
+	if err != nil {
+		return "", false, fmt.Errorf("%w: invalid date time %q", ErrInvalidICal, value)
+	}
+
+	return at.In(time.Local).Format(time.DateOnly), false, nil
+}
+
+func unescapeICalText(text string) string {
+	return strings.NewReplacer(`\n`, " ", `\N`, " ", `\,`, ",", `\;`, ";", `\\`, `\`).Replace(text)
+}
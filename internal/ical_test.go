@@ -0,0 +1,60 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const testCalendar = "BEGIN:VCALENDAR\r\n" +
+	"VERSION:2.0\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Ana: Férias\\, finalmente\r\n" +
+	"DTSTART;VALUE=DATE:20261019\r\n" +
+	"DTEND;VALUE=DATE:20261024\r\n" +
+	"ORGANIZER:mailto:bruno@example.com\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Consulta\r\n" +
+	"DTSTART:20261020T130000Z\r\n" +
+	"DTEND:20261020T150000Z\r\n" +
+	"ORGANIZER;CN=Bruno:mailto:Bruno@Example.com\r\n" +
+	"ATTENDEE;CN=Carla;ROLE=REQ-PARTICIPANT:mailto:carla@exam\r\n" +
+	" ple.com\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Folga\r\n" +
+	"DTSTART;VALUE=DATE:20261101\r\n" +
+	"END:VEVENT\r\n" +
+	"BEGIN:VEVENT\r\n" +
+	"SUMMARY:Dentista\r\n" +
+	"DTSTART;VALUE=DATE:20261105\r\n" +
+	"ORGANIZER:mailto:bruno@example.com\r\n" +
+	"END:VEVENT\r\n" +
+	"END:VCALENDAR\r\n"
+
+func TestParseICal(t *testing.T) {
+	events, err := ParseICal(strings.NewReader(testCalendar))
+	require.NoError(t, err)
+	require.Len(t, events, 4)
+
+	assert.Equal(t, ICalEvent{Summary: "Ana: Férias, finalmente", Start: "2026-10-19", End: "2026-10-23", Organizer: "bruno@example.com"}, events[0])
+	assert.Equal(t, "2026-10-20", events[1].Start)
+	assert.Equal(t, "2026-10-20", events[1].End)
+	assert.Equal(t, "bruno@example.com", events[1].Organizer)
+	assert.Equal(t, []string{"carla@example.com"}, events[1].Attendees)
+	assert.Equal(t, "2026-11-01", events[2].End)
+}
+
+func TestParseICalInvalid(t *testing.T) {
+	for _, calendar := range []string{
+		"",
+		"BEGIN:VCALENDAR\nBEGIN:VEVENT\nSUMMARY:Férias\nEND:VEVENT\nEND:VCALENDAR\n",
+		"BEGIN:VCALENDAR\nBEGIN:VEVENT\nDTSTART;VALUE=DATE:2026-10-19\nEND:VEVENT\nEND:VCALENDAR\n",
+	} {
+		_, err := ParseICal(strings.NewReader(calendar))
+		assert.ErrorIs(t, err, ErrInvalidICal, calendar)
+	}
+}
@@ -0,0 +1,288 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"log/slog"
+	"math/rand"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// default images of the platforms that can only link to an image, used while
+// the library has nothing for the card
+const (
+	DefaultMessageImageURL = "https://w7.pngwing.com/pngs/504/252/png-transparent-pepe-the-frog-television-meme-meme-television-vertebrate-grass-thumbnail.png"
+	DefaultBrokenImageURL  = "https://preview.redd.it/coomer-meme-please-v0-oczzteliqb5c1.png?width=2004&format=png&auto=webp&s=305ec437dcf4f04b779cb238dfaeb114abe2896a"
+)
+
+// the tags looked up for the cards that aren't tagged messages
+const (
+	ImageTagBroken = "broken"
+	ImageTagDigest = "digest"
+)
+
+// AssetScheme marks the images served from the local assets instead of a
+// public host, only the platforms that upload files can show them
+const AssetScheme = "asset://"
+
+var (
+	ErrImageNotFound = errors.New("image not found")
+)
+
+type Image struct {
+	Id        string    `json:"id"`
+	URL       string    `json:"url"`
+	Tags      []string  `json:"tags"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type ImageStorer interface {
+	AddImage(ctx context.Context, image Image) error
+	GetAllImages(ctx context.Context) ([]Image, error)
+	GetImageByID(ctx context.Context, id string) (*Image, error)
+	DeleteImage(ctx context.Context, id string) error
+}
+
+type InMemoryImageStorer struct {
+	mu     sync.RWMutex
+	images []Image
+}
+
+var (
+	_ ImageStorer = (*InMemoryImageStorer)(nil)
+)
+
+func NewInMemoryImageStorer(images ...Image) *InMemoryImageStorer {
+	return &InMemoryImageStorer{
+		images: images,
+	}
+}
+
+func (s *InMemoryImageStorer) AddImage(ctx context.Context, image Image) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.images = append(s.images, image)
+
+	return nil
+}
+
+func (s *InMemoryImageStorer) GetAllImages(ctx context.Context) ([]Image, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.images), nil
+}
+
+func (s *InMemoryImageStorer) GetImageByID(ctx context.Context, id string) (*Image, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, image := range s.images {
+		if image.Id == id {
+			return &image, nil
+		}
+	}
+
+	return nil, ErrImageNotFound
+}
+
+func (s *InMemoryImageStorer) DeleteImage(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.images, func(image Image) bool { return image.Id == id })
+	if i < 0 {
+		return ErrImageNotFound
+	}
+
+	s.images = slices.Delete(s.images, i, i+1)
+
+	return nil
+}
+
+// AssetName returns the file name of an asset image
+func AssetName(imageURL string) (string, bool) {
+	name, ok := strings.CutPrefix(imageURL, AssetScheme)
+	if !ok || name == "" || !fs.ValidPath(name) {
+		return "", false
+	}
+
+	return name, true
+}
+
+// ImageOrDefault keeps the cards rendered outside the image library, like the
+// previews and command replies, with an image. Asset images also fall back,
+// they can only be linked by the platforms that upload them
+func ImageOrDefault(imageURL string, fallback string) string {
+	if imageURL == "" || strings.HasPrefix(imageURL, AssetScheme) {
+		return fallback
+	}
+
+	return imageURL
+}
+
+type pinnedImageKey struct{}
+
+// WithPinnedImage makes the cards sent with the context use the image, it is
+// how a schedule pins its image
+func WithPinnedImage(ctx context.Context, imageID string) context.Context {
+	if imageID == "" {
+		return ctx
+	}
+
+	return context.WithValue(ctx, pinnedImageKey{}, imageID)
+}
+
+// PickImage returns the pinned image when there is one, otherwise a random
+// image sharing a tag with the card, otherwise any random image. It returns
+// the fallback when the library is empty
+func PickImage(ctx context.Context, storer ImageStorer, tags []string, fallback string) (string, error) {
+	if imageID, ok := ctx.Value(pinnedImageKey{}).(string); ok {
+		image, err := storer.GetImageByID(ctx, imageID)
+		if err != nil {
+			return "", err
+		}
+
+		return image.URL, nil
+	}
+
+	images, err := storer.GetAllImages(ctx)
+	if err != nil {
+		return "", err
+	}
+
+	matching := slices.DeleteFunc(slices.Clone(images), func(image Image) bool {
+		return !slices.ContainsFunc(image.Tags, func(tag string) bool {
+			return slices.Contains(tags, tag)
+		})
+	})
+	if len(matching) > 0 {
+		images = matching
+	}
+
+	if len(images) == 0 {
+		return fallback, nil
+	}
+
+	return images[rand.Intn(len(images))].URL, nil
+}
+
+// ImageMessageSender decorates a MessageSender choosing the image of every
+// card that doesn't have one yet
+type ImageMessageSender struct {
+	next   MessageSender
+	images ImageStorer
+}
+
+var (
+	_ MessageSender = (*ImageMessageSender)(nil)
+)
+
+func NewImageMessageSender(next MessageSender, images ImageStorer) *ImageMessageSender {
+	return &ImageMessageSender{
+		next:   next,
+		images: images,
+	}
+}
+
+func (s *ImageMessageSender) SendMessage(ctx context.Context, message Message) error {
+	if message.ImageURL == "" {
+		message.ImageURL = s.pick(ctx, message.Tags)
+	}
+
+	RecordCardImage(ctx, message.ImageURL)
+
+	return s.next.SendMessage(ctx, message)
+}
+
+func (s *ImageMessageSender) SendBrokenMessage(ctx context.Context, message BrokenMessage) error {
+	if message.ImageURL == "" {
+		message.ImageURL = s.pick(ctx, []string{ImageTagBroken})
+	}
+
+	return s.next.SendBrokenMessage(ctx, message)
+}
+
+func (s *ImageMessageSender) SendDigest(ctx context.Context, digest Digest) error {
+	if digest.ImageURL == "" {
+		digest.ImageURL = s.pick(ctx, []string{ImageTagDigest})
+	}
+
+	return s.next.SendDigest(ctx, digest)
+}
+
+func (s *ImageMessageSender) SendAlert(ctx context.Context, notification AlertNotification) error {
+	return s.next.SendAlert(ctx, notification)
+}
+
+// pick never fails the send, without an image each platform renders the card
+// with its own default
+func (s *ImageMessageSender) pick(ctx context.Context, tags []string) string {
+	url, err := PickImage(ctx, s.images, tags, "")
+	if err != nil {
+		slog.WarnContext(ctx, "failed to pick card image, using the default", slog.Any("error", err))
+		return ""
+	}
+
+	return url
+}
+
+type createImageRequest struct {
+	URL  string   `json:"url"`
+	Tags []string `json:"tags"`
+}
+
+func (s *Server) GetAllImages(c echo.Context) error {
+	images, err := s.imageStorer.GetAllImages(c.Request().Context())
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(200, images)
+}
+
+func (s *Server) CreateImage(c echo.Context) error {
+	var req createImageRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(400, map[string]string{"error": "invalid request"})
+	}
+
+	_, isAsset := AssetName(req.URL)
+	if !isAsset && !isAbsoluteHTTPURL(req.URL) {
+		return c.JSON(400, map[string]string{"error": "url must be an absolute http url or an asset:// name"})
+	}
+
+	image := Image{
+		Id:        uuid.NewString(),
+		URL:       req.URL,
+		Tags:      req.Tags,
+		CreatedAt: time.Now(),
+	}
+
+	err := s.imageStorer.AddImage(c.Request().Context(), image)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(201, image)
+}
+
+func (s *Server) DeleteImage(c echo.Context) error {
+	err := s.imageStorer.DeleteImage(c.Request().Context(), c.Param("id"))
+	if errors.Is(err, ErrImageNotFound) {
+		return c.JSON(404, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	return c.NoContent(204)
+}
@@ -0,0 +1,148 @@
+package internal
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPickImage(t *testing.T) {
+	storer := NewInMemoryImageStorer(
+		Image{Id: "1", URL: "https://example.com/pepe.png", Tags: []string{"pepe"}},
+		Image{Id: "2", URL: "https://example.com/coomer.png", Tags: []string{ImageTagBroken}},
+	)
+
+	t.Run("pinned image wins", func(t *testing.T) {
+		ctx := WithPinnedImage(t.Context(), "2")
+
+		url, err := PickImage(ctx, storer, []string{"pepe"}, "fallback")
+		require.NoError(t, err)
+		assert.Equal(t, "https://example.com/coomer.png", url)
+	})
+
+	t.Run("missing pinned image fails", func(t *testing.T) {
+		ctx := WithPinnedImage(t.Context(), "404")
+
+		_, err := PickImage(ctx, storer, nil, "fallback")
+		assert.ErrorIs(t, err, ErrImageNotFound)
+	})
+
+	t.Run("tag match", func(t *testing.T) {
+		for range 10 {
+			url, err := PickImage(t.Context(), storer, []string{ImageTagBroken}, "fallback")
+			require.NoError(t, err)
+			assert.Equal(t, "https://example.com/coomer.png", url)
+		}
+	})
+
+	t.Run("any image without a tag match", func(t *testing.T) {
+		url, err := PickImage(t.Context(), storer, []string{"digest"}, "fallback")
+		require.NoError(t, err)
+		assert.Contains(t, []string{"https://example.com/pepe.png", "https://example.com/coomer.png"}, url)
+	})
+
+	t.Run("fallback on empty library", func(t *testing.T) {
+		url, err := PickImage(t.Context(), NewInMemoryImageStorer(), []string{"pepe"}, "fallback")
+		require.NoError(t, err)
+		assert.Equal(t, "fallback", url)
+	})
+}
+
+func TestImageMessageSender(t *testing.T) {
+	storer := NewInMemoryImageStorer(Image{Id: "1", URL: "https://example.com/pepe.png"})
+
+	t.Run("fills the image", func(t *testing.T) {
+		mockSender := NewMockMessageSender(t)
+		mockSender.On("SendMessage", mock.Anything, Message{Id: "1", ImageURL: "https://example.com/pepe.png"}).Return(nil)
+
+		err := NewImageMessageSender(mockSender, storer).SendMessage(t.Context(), Message{Id: "1"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("keeps the image already set", func(t *testing.T) {
+		mockSender := NewMockMessageSender(t)
+		mockSender.On("SendBrokenMessage", mock.Anything, BrokenMessage{Id: "1", ImageURL: "https://example.com/mine.png"}).Return(nil)
+
+		err := NewImageMessageSender(mockSender, storer).SendBrokenMessage(t.Context(), BrokenMessage{Id: "1", ImageURL: "https://example.com/mine.png"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("leaves the default to the platform when the storer fails", func(t *testing.T) {
+		failing := NewMockImageStorer(t)
+		failing.On("GetAllImages", mock.Anything).Return(nil, errors.New("boom"))
+
+		mockSender := NewMockMessageSender(t)
+		mockSender.On("SendDigest", mock.Anything, Digest{Id: "1"}).Return(nil)
+
+		err := NewImageMessageSender(mockSender, failing).SendDigest(t.Context(), Digest{Id: "1"})
+		assert.NoError(t, err)
+	})
+}
+
+func TestCreateImage(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		expectedCode int
+	}{
+		{name: "valid", body: `{"url":"https://example.com/pepe.png","tags":["pepe"]}`, expectedCode: http.StatusCreated},
+		{name: "asset", body: `{"url":"asset://pepe.png"}`, expectedCode: http.StatusCreated},
+		{name: "asset escaping the directory", body: `{"url":"asset://../pepe.png"}`, expectedCode: http.StatusBadRequest},
+		{name: "relative url", body: `{"url":"/pepe.png"}`, expectedCode: http.StatusBadRequest},
+		{name: "invalid body", body: `{`, expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			storer := NewInMemoryImageStorer()
+			server := &Server{imageStorer: storer, echoServer: e}
+
+			req := httptest.NewRequest(http.MethodPost, "/images", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+
+			err := server.CreateImage(e.NewContext(req, rec))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rec.Code)
+
+			images, err := storer.GetAllImages(t.Context())
+			require.NoError(t, err)
+			if tt.expectedCode == http.StatusCreated {
+				assert.Len(t, images, 1)
+			} else {
+				assert.Empty(t, images)
+			}
+		})
+	}
+}
+
+func TestDeleteImage(t *testing.T) {
+	e := echo.New()
+	server := &Server{imageStorer: NewInMemoryImageStorer(Image{Id: "1"}), echoServer: e}
+
+	for _, expectedCode := range []int{http.StatusNoContent, http.StatusNotFound} {
+		req := httptest.NewRequest(http.MethodDelete, "/images/1", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		err := server.DeleteImage(c)
+		require.NoError(t, err)
+		assert.Equal(t, expectedCode, rec.Code)
+	}
+}
+
+func TestImageOrDefault(t *testing.T) {
+	assert.Equal(t, "fallback", ImageOrDefault("", "fallback"))
+	assert.Equal(t, "fallback", ImageOrDefault("asset://pepe.png", "fallback"))
+	assert.Equal(t, "https://example.com/pepe.png", ImageOrDefault("https://example.com/pepe.png", "fallback"))
+}
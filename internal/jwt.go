@@ -0,0 +1,238 @@
+package internal
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	jwksCacheTTL         = time.Hour
+	jwksMinRefreshPeriod = time.Minute
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrUnknownKey   = errors.New("unknown signing key")
+)
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type jwtClaims struct {
+	Issuer    string          `json:"iss"`
+	Audience  json.RawMessage `json:"aud"`
+	ExpiresAt int64           `json:"exp"`
+	IssuedAt  int64           `json:"iat"`
+}
+
+type jwk struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+type jwks struct {
+	Keys []jwk `json:"keys"`
+}
+
+// JWKSVerifier verifies RS256 signed JWTs against the keys published in a
+// JWKS endpoint, keys are cached and refreshed when an unknown kid shows up
+type JWKSVerifier struct {
+	jwksURL    string
+	issuer     string
+	audience   string
+	httpClient *http.Client
+	now        func() time.Time
+
+	mu        sync.Mutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+}
+
+func NewJWKSVerifier(jwksURL string, issuer string, audience string) *JWKSVerifier {
+	return &JWKSVerifier{
+		jwksURL:    jwksURL,
+		issuer:     issuer,
+		audience:   audience,
+		httpClient: NewTracedHTTPClient(10 * time.Second),
+		now:        time.Now,
+	}
+}
+
+// Verify checks the signature, issuer, audience and expiration of the token
+func (v *JWKSVerifier) Verify(ctx context.Context, token string) error {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return ErrInvalidToken
+	}
+
+	var header jwtHeader
+	if err := decodeSegment(parts[0], &header); err != nil {
+		return ErrInvalidToken
+	}
+
+	if header.Alg != "RS256" {
+		return fmt.Errorf("%w: unsupported algorithm %q", ErrInvalidToken, header.Alg)
+	}
+
+	key, err := v.key(ctx, header.Kid)
+	if err != nil {
+		return err
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return ErrInvalidToken
+	}
+
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if err := rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature); err != nil {
+		return fmt.Errorf("%w: bad signature", ErrInvalidToken)
+	}
+
+	var claims jwtClaims
+	if err := decodeSegment(parts[1], &claims); err != nil {
+		return ErrInvalidToken
+	}
+
+	if claims.Issuer != v.issuer {
+		return fmt.Errorf("%w: unexpected issuer %q", ErrInvalidToken, claims.Issuer)
+	}
+
+	if !claims.hasAudience(v.audience) {
+		return fmt.Errorf("%w: unexpected audience", ErrInvalidToken)
+	}
+
+	if v.now().Unix() >= claims.ExpiresAt {
+		return fmt.Errorf("%w: token expired", ErrInvalidToken)
+	}
+
+	return nil
+}
+
+func (c jwtClaims) hasAudience(audience string) bool {
+	var single string
+	if err := json.Unmarshal(c.Audience, &single); err == nil {
+		return single == audience
+	}
+
+	var many []string
+	if err := json.Unmarshal(c.Audience, &many); err == nil {
+		return slices.Contains(many, audience)
+	}
+
+	return false
+}
+
+func (v *JWKSVerifier) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+
+	age := v.now().Sub(v.fetchedAt)
+
+	key, ok := v.keys[kid]
+	if ok && age < jwksCacheTTL {
+		return key, nil
+	}
+
+	if !ok && v.keys != nil && age < jwksMinRefreshPeriod {
+		return nil, ErrUnknownKey
+	}
+
+	err := v.refresh(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to refresh jwks", slog.Any("error", err))
+		return nil, err
+	}
+
+	key, ok = v.keys[kid]
+	if !ok {
+		return nil, ErrUnknownKey
+	}
+
+	return key, nil
+}
+
+func (v *JWKSVerifier) refresh(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.jwksURL, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := v.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("unexpected jwks status code %d", resp.StatusCode)
+	}
+
+	var set jwks
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return err
+	}
+
+	keys := make(map[string]*rsa.PublicKey, len(set.Keys))
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+
+		key, err := k.rsaPublicKey()
+		if err != nil {
+			slog.WarnContext(ctx, "skipping invalid jwk", slog.String("kid", k.Kid), slog.Any("error", err))
+			continue
+		}
+
+		keys[k.Kid] = key
+	}
+
+	v.keys = keys
+	v.fetchedAt = v.now()
+
+	return nil
+}
+
+func (k jwk) rsaPublicKey() (*rsa.PublicKey, error) {
+	n, err := base64.RawURLEncoding.DecodeString(k.N)
+	if err != nil {
+		return nil, err
+	}
+
+	e, err := base64.RawURLEncoding.DecodeString(k.E)
+	if err != nil {
+		return nil, err
+	}
+
+	return &rsa.PublicKey{
+		N: new(big.Int).SetBytes(n),
+		E: int(new(big.Int).SetBytes(e).Int64()),
+	}, nil
+}
+
+func decodeSegment(segment string, v any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+
+	return json.Unmarshal(raw, v)
+}
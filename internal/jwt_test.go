@@ -0,0 +1,90 @@
+package internal
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// jwksStub serves a JWKS with a locally generated key and signs tokens with it
+type jwksStub struct {
+	server *httptest.Server
+	key    *rsa.PrivateKey
+	kid    string
+}
+
+func newJWKSStub(t *testing.T) *jwksStub {
+	t.Helper()
+
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+
+	stub := &jwksStub{key: key, kid: "test-kid"}
+	stub.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_ = json.NewEncoder(w).Encode(jwks{Keys: []jwk{{
+			Kid: stub.kid,
+			Kty: "RSA",
+			N:   base64.RawURLEncoding.EncodeToString(key.N.Bytes()),
+			E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(key.E)).Bytes()),
+		}}})
+	}))
+	t.Cleanup(stub.server.Close)
+
+	return stub
+}
+
+func (s *jwksStub) sign(t *testing.T, claims map[string]any) string {
+	t.Helper()
+
+	header, _ := json.Marshal(jwtHeader{Alg: "RS256", Kid: s.kid})
+	payload, _ := json.Marshal(claims)
+
+	signingInput := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signingInput))
+
+	signature, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	require.NoError(t, err)
+
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestJWKSVerifier(t *testing.T) {
+	ctx := context.Background()
+	stub := newJWKSStub(t)
+	verifier := NewJWKSVerifier(stub.server.URL, "chat@system.gserviceaccount.com", "1234")
+
+	validClaims := map[string]any{
+		"iss": "chat@system.gserviceaccount.com",
+		"aud": "1234",
+		"exp": time.Now().Add(time.Hour).Unix(),
+	}
+
+	assert.NoError(t, verifier.Verify(ctx, stub.sign(t, validClaims)))
+
+	expired := map[string]any{"iss": validClaims["iss"], "aud": "1234", "exp": time.Now().Add(-time.Hour).Unix()}
+	assert.ErrorIs(t, verifier.Verify(ctx, stub.sign(t, expired)), ErrInvalidToken)
+
+	wrongAudience := map[string]any{"iss": validClaims["iss"], "aud": []string{"4321"}, "exp": validClaims["exp"]}
+	assert.ErrorIs(t, verifier.Verify(ctx, stub.sign(t, wrongAudience)), ErrInvalidToken)
+
+	wrongIssuer := map[string]any{"iss": "someone@else.com", "aud": "1234", "exp": validClaims["exp"]}
+	assert.ErrorIs(t, verifier.Verify(ctx, stub.sign(t, wrongIssuer)), ErrInvalidToken)
+
+	assert.ErrorIs(t, verifier.Verify(ctx, "not.a.token"), ErrInvalidToken)
+
+	otherStub := newJWKSStub(t)
+	otherStub.kid = "other-kid"
+	assert.ErrorIs(t, verifier.Verify(ctx, otherStub.sign(t, validClaims)), ErrUnknownKey)
+}
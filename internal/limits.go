@@ -0,0 +1,124 @@
+package internal
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// LimitMode decides what happens to a text longer than the platform accepts
+type LimitMode string
+
+const (
+	// LimitModeTruncate cuts the text, ending it with an ellipsis
+	LimitModeTruncate LimitMode = "truncate"
+	// LimitModeSplit keeps what fits in the card and sends the rest in
+	// follow up posts
+	LimitModeSplit LimitMode = "split"
+)
+
+const ellipsis = "…"
+
+// Validate accepts the known modes, empty means truncate
+func (m LimitMode) Validate() error {
+	switch m {
+	case "", LimitModeTruncate, LimitModeSplit:
+		return nil
+	default:
+		return fmt.Errorf("invalid limit mode %q", m)
+	}
+}
+
+// TruncateText cuts the text to limit characters, the ellipsis included. No
+// room at all gives an empty text
+func TruncateText(text string, limit int) string {
+	if utf8.RuneCountInString(text) <= limit {
+		return text
+	}
+
+	if limit < 1 {
+		return ""
+	}
+
+	runes := []rune(text)
+
+	return strings.TrimRightFunc(string(runes[:limit-1]), unicode.IsSpace) + ellipsis
+}
+
+// SplitText breaks the text in chunks of at most limit characters, preferring
+// to break at a space in the second half of the chunk
+func SplitText(text string, limit int) []string {
+	head, rest := splitFirst(text, limit)
+
+	chunks := []string{head}
+	for rest != "" {
+		head, rest = splitFirst(rest, limit)
+		chunks = append(chunks, head)
+	}
+
+	return chunks
+}
+
+// splitFirst returns the first chunk of the text and what is left after it,
+// the text is dropped when there is no room at all
+func splitFirst(text string, limit int) (string, string) {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text, ""
+	}
+
+	if limit < 1 {
+		return "", ""
+	}
+
+	cut := limit
+	for i := limit; i > limit/2; i-- {
+		if unicode.IsSpace(runes[i]) {
+			cut = i
+			break
+		}
+	}
+
+	head := strings.TrimRightFunc(string(runes[:cut]), unicode.IsSpace)
+	rest := strings.TrimLeftFunc(string(runes[cut:]), unicode.IsSpace)
+
+	return head, rest
+}
+
+// Fitter fits the texts of a card into the platform limits, keeping what
+// doesn't fit for the follow up posts when splitting
+type Fitter struct {
+	mode     LimitMode
+	overflow []string
+}
+
+func NewFitter(mode LimitMode) *Fitter {
+	return &Fitter{
+		mode: mode,
+	}
+}
+
+// Fit returns the part of the text shown in the card
+func (f *Fitter) Fit(text string, limit int) string {
+	if f.mode != LimitModeSplit {
+		return TruncateText(text, limit)
+	}
+
+	head, rest := splitFirst(text, limit)
+	if rest != "" {
+		f.overflow = append(f.overflow, rest)
+	}
+
+	return head
+}
+
+// FollowUps splits the overflow in posts of at most limit characters
+func (f *Fitter) FollowUps(limit int) []string {
+	var posts []string
+	for _, text := range f.overflow {
+		posts = append(posts, SplitText(text, limit)...)
+	}
+
+	return posts
+}
@@ -0,0 +1,80 @@
+package internal
+
+import (
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTruncateText(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		limit    int
+		expected string
+	}{
+		{name: "fits", text: "Wilson", limit: 6, expected: "Wilson"},
+		{name: "cut with ellipsis", text: "Wilson quebrou", limit: 8, expected: "Wilson…"},
+		{name: "counts characters", text: "ááááá", limit: 4, expected: "ááá…"},
+		{name: "only the ellipsis", text: "Wilson", limit: 1, expected: "…"},
+		{name: "no room", text: "Wilson", limit: 0, expected: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, TruncateText(tt.text, tt.limit))
+		})
+	}
+}
+
+func TestSplitText(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		limit    int
+		expected []string
+	}{
+		{name: "fits", text: "Wilson", limit: 10, expected: []string{"Wilson"}},
+		{name: "breaks at spaces", text: "deu push na main", limit: 8, expected: []string{"deu push", "na main"}},
+		{name: "hard cut without spaces", text: "aaaaaaaaaa", limit: 4, expected: []string{"aaaa", "aaaa", "aa"}},
+		{name: "empty", text: "", limit: 4, expected: []string{""}},
+		{name: "no room", text: "Wilson", limit: 0, expected: []string{""}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, SplitText(tt.text, tt.limit))
+		})
+	}
+}
+
+func TestFitter(t *testing.T) {
+	text := strings.Repeat("Wilson quebrou a build ", 100)
+
+	t.Run("truncate", func(t *testing.T) {
+		fit := NewFitter(LimitModeTruncate)
+
+		shown := fit.Fit(text, 100)
+		assert.LessOrEqual(t, utf8.RuneCountInString(shown), 100)
+		assert.True(t, strings.HasSuffix(shown, "…"))
+		assert.Empty(t, fit.FollowUps(50))
+	})
+
+	t.Run("split", func(t *testing.T) {
+		fit := NewFitter(LimitModeSplit)
+
+		shown := fit.Fit(text, 100)
+		assert.LessOrEqual(t, utf8.RuneCountInString(shown), 100)
+
+		followUps := fit.FollowUps(500)
+		for _, post := range followUps {
+			assert.LessOrEqual(t, utf8.RuneCountInString(post), 500)
+		}
+		assert.Equal(t, strings.Fields(text), strings.Fields(shown+" "+strings.Join(followUps, " ")))
+	})
+
+	assert.NoError(t, LimitMode("").Validate())
+	assert.Error(t, LimitMode("drop").Validate())
+}
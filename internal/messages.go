@@ -6,6 +6,11 @@ import (
 	"encoding/json"
 	"errors"
 	"log/slog"
+	"math/rand"
+	"slices"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 //go:embed messages.json
@@ -20,6 +25,7 @@ type Message struct {
 	Message   string   `json:"message"`
 	Sentiment string   `json:"sentiment"`
 	Tags      []string `json:"tags"`
+	ImageURL  string   `json:"image_url,omitempty"`
 }
 
 type BrokenMessage struct {
@@ -28,6 +34,7 @@ type BrokenMessage struct {
 	Motive          string `json:"motive"`
 	TimeSinceBroken string `json:"time_since_broken"`
 	DayOfBreakage   string `json:"day_of_breakage"`
+	ImageURL        string `json:"image_url,omitempty"`
 }
 
 func GetMessages(ctx context.Context, rawMessagesData []byte) ([]Message, error) {
@@ -42,6 +49,29 @@ func GetMessages(ctx context.Context, rawMessagesData []byte) ([]Message, error)
 	return messages, nil
 }
 
+// GetRandomMessage picks a random message, only considering the ones with
+// the given tag when it is not empty
+func GetRandomMessage(ctx context.Context, messageStorer MessageStorer, tag string) (*Message, error) {
+	messages, err := messageStorer.GetAllMessages(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if tag != "" {
+		messages = slices.DeleteFunc(slices.Clone(messages), func(m Message) bool {
+			return !slices.Contains(m.Tags, tag)
+		})
+	}
+
+	if len(messages) == 0 {
+		return nil, ErrMessageNotFound
+	}
+
+	message := messages[rand.Intn(len(messages))]
+
+	return &message, nil
+}
+
 type MessageStorer interface {
 	GetAllMessages(ctx context.Context) ([]Message, error)
 	GetMessageByID(ctx context.Context, id string) (*Message, error)
@@ -62,10 +92,16 @@ func NewMessageStorer(messages []Message) *DumpMessageStorer {
 }
 
 func (s *DumpMessageStorer) GetAllMessages(ctx context.Context) ([]Message, error) {
+	_, span := Tracer().Start(ctx, "DumpMessageStorer.GetAllMessages")
+	defer span.End()
+
 	return s.Messages, nil
 }
 
 func (s *DumpMessageStorer) GetMessageByID(ctx context.Context, id string) (*Message, error) {
+	_, span := Tracer().Start(ctx, "DumpMessageStorer.GetMessageByID", trace.WithAttributes(attribute.String("message.id", id)))
+	defer span.End()
+
 	for _, m := range s.Messages {
 		if m.Id == id {
 			return &m, nil
@@ -62,3 +62,24 @@ func TestDumpMessageStorerGetMessageByID(t *testing.T) {
 	assert.ErrorIs(t, err, ErrMessageNotFound)
 	assert.Nil(t, message)
 }
+
+func TestGetRandomMessage(t *testing.T) {
+	ctx := context.Background()
+	storer := NewMessageStorer([]Message{
+		{Id: "1", Message: "Hello", Tags: []string{"greeting"}},
+		{Id: "2", Message: "Bye", Tags: []string{"farewell"}},
+	})
+
+	message, err := GetRandomMessage(ctx, storer, "farewell")
+	assert.NoError(t, err)
+	assert.Equal(t, "2", message.Id)
+
+	message, err = GetRandomMessage(ctx, storer, "")
+	assert.NoError(t, err)
+	assert.NotNil(t, message)
+
+	message, err = GetRandomMessage(ctx, storer, "tech")
+	assert.ErrorIs(t, err, ErrMessageNotFound)
+	assert.Nil(t, message)
+	assert.Len(t, storer.Messages, 2)
+}
@@ -0,0 +1,207 @@
+package internal
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const metricsNamespace = "wilsonbot"
+
+// triggers label what caused a message to be sent
+const (
+	TriggerCron    = "cron"
+	TriggerAPI     = "api"
+	TriggerWebhook = "webhook"
+	TriggerCommand = "command"
+	TriggerUnknown = "unknown"
+)
+
+const (
+	outcomeSuccess = "success"
+	outcomeFailure = "failure"
+)
+
+// unknownMember labels the breakages of who isn't in the roster
+const unknownMember = "unknown"
+
+var (
+	messagesSentTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "messages_sent_total",
+		Help:      "Messages sent by platform, kind, trigger and outcome.",
+	}, []string{"platform", "kind", "trigger", "outcome"})
+
+	webhookDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "webhook_request_duration_seconds",
+		Help:      "Latency of the outgoing webhook requests to the chat platforms.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"platform", "kind"})
+
+	cronRunsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "cron_runs_total",
+		Help:      "Cron job runs by job and outcome.",
+	}, []string{"job", "outcome"})
+
+	cronSkipsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "cron_skips_total",
+		Help:      "Cron job runs that had nothing to send.",
+	}, []string{"job"})
+
+	breakagesTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "breakages_total",
+		Help:      "Breakages recorded by roster member, unknown for anyone else.",
+	}, []string{"member"})
+
+	httpRequestsTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: metricsNamespace,
+		Name:      "http_requests_total",
+		Help:      "HTTP requests by method, route and status code.",
+	}, []string{"method", "route", "code"})
+
+	httpRequestDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: metricsNamespace,
+		Name:      "http_request_duration_seconds",
+		Help:      "HTTP request latency by method and route.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route"})
+)
+
+type triggerKey struct{}
+
+// WithTrigger tags the context with what caused the messages sent with it
+func WithTrigger(ctx context.Context, trigger string) context.Context {
+	return context.WithValue(ctx, triggerKey{}, trigger)
+}
+
+func triggerFromContext(ctx context.Context) string {
+	trigger, ok := ctx.Value(triggerKey{}).(string)
+	if !ok {
+		return TriggerUnknown
+	}
+
+	return trigger
+}
+
+// TriggerMiddleware tags the request context with the trigger
+func TriggerMiddleware(trigger string) echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			req := c.Request()
+			c.SetRequest(req.WithContext(WithTrigger(req.Context(), trigger)))
+
+			return next(c)
+		}
+	}
+}
+
+// metricsMiddleware records the HTTP metrics by route template, keeping the
+// cardinality bounded
+func metricsMiddleware() echo.MiddlewareFunc {
+	return func(next echo.HandlerFunc) echo.HandlerFunc {
+		return func(c echo.Context) error {
+			start := time.Now()
+
+			err := next(c)
+			if err != nil {
+				c.Error(err)
+			}
+
+			route := c.Path()
+			if route == "" {
+				route = "unmatched"
+			}
+
+			method := c.Request().Method
+			httpRequestsTotal.WithLabelValues(method, route, strconv.Itoa(c.Response().Status)).Inc()
+			httpRequestDuration.WithLabelValues(method, route).Observe(time.Since(start).Seconds())
+
+			return nil
+		}
+	}
+}
+
+func metricsHandler() echo.HandlerFunc {
+	return echo.WrapHandler(promhttp.Handler())
+}
+
+func observeCronRun(job string, err error) {
+	outcome := outcomeSuccess
+	if err != nil {
+		outcome = outcomeFailure
+	}
+
+	cronRunsTotal.WithLabelValues(job, outcome).Inc()
+}
+
+func observeCronSkip(job string) {
+	cronSkipsTotal.WithLabelValues(job).Inc()
+}
+
+// InstrumentedMessageSender decorates the MessageSender of a single platform
+// counting every send and measuring its latency
+type InstrumentedMessageSender struct {
+	platform string
+	next     MessageSender
+}
+
+var (
+	_ MessageSender = (*InstrumentedMessageSender)(nil)
+)
+
+func NewInstrumentedMessageSender(platform string, next MessageSender) *InstrumentedMessageSender {
+	return &InstrumentedMessageSender{
+		platform: platform,
+		next:     next,
+	}
+}
+
+func (s *InstrumentedMessageSender) SendMessage(ctx context.Context, message Message) error {
+	return s.observe(ctx, SendKindMessage, func() error {
+		return s.next.SendMessage(ctx, message)
+	})
+}
+
+func (s *InstrumentedMessageSender) SendBrokenMessage(ctx context.Context, message BrokenMessage) error {
+	return s.observe(ctx, SendKindBroken, func() error {
+		return s.next.SendBrokenMessage(ctx, message)
+	})
+}
+
+func (s *InstrumentedMessageSender) SendDigest(ctx context.Context, digest Digest) error {
+	return s.observe(ctx, SendKindDigest, func() error {
+		return s.next.SendDigest(ctx, digest)
+	})
+}
+
+func (s *InstrumentedMessageSender) SendAlert(ctx context.Context, notification AlertNotification) error {
+	return s.observe(ctx, SendKindAlert, func() error {
+		return s.next.SendAlert(ctx, notification)
+	})
+}
+
+func (s *InstrumentedMessageSender) observe(ctx context.Context, kind string, send func() error) error {
+	start := time.Now()
+
+	err := send()
+
+	webhookDuration.WithLabelValues(s.platform, kind).Observe(time.Since(start).Seconds())
+
+	outcome := outcomeSuccess
+	if err != nil {
+		outcome = outcomeFailure
+	}
+
+	messagesSentTotal.WithLabelValues(s.platform, kind, triggerFromContext(ctx), outcome).Inc()
+
+	return err
+}
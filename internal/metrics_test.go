@@ -0,0 +1,69 @@
+package internal
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestInstrumentedMessageSender(t *testing.T) {
+	mockSender := NewMockMessageSender(t)
+	mockSender.On("SendMessage", mock.Anything, mock.Anything).Return(nil).Once()
+	mockSender.On("SendMessage", mock.Anything, mock.Anything).Return(errors.New("boom")).Once()
+
+	sender := NewInstrumentedMessageSender("test", mockSender)
+	ctx := WithTrigger(t.Context(), TriggerCron)
+
+	success := messagesSentTotal.WithLabelValues("test", SendKindMessage, TriggerCron, outcomeSuccess)
+	failure := messagesSentTotal.WithLabelValues("test", SendKindMessage, TriggerCron, outcomeFailure)
+	successBefore, failureBefore := testutil.ToFloat64(success), testutil.ToFloat64(failure)
+
+	assert.NoError(t, sender.SendMessage(ctx, Message{Id: "1"}))
+	assert.Error(t, sender.SendMessage(ctx, Message{Id: "2"}))
+
+	assert.Equal(t, successBefore+1, testutil.ToFloat64(success))
+	assert.Equal(t, failureBefore+1, testutil.ToFloat64(failure))
+}
+
+func TestMetricsEndpoint(t *testing.T) {
+	server := NewServer(HTTPConfig{
+		Prefix:  "/api",
+		Metrics: MetricsConfig{Enabled: true, Path: "/metrics"},
+	}, NewMockMessageStorer(t), NewMockMessageSender(t), NewMockAPIKeyStorer(t), NewInMemoryImageStorer(), NewInMemoryHistoryStorer(), NewInMemoryRosterStorer())
+
+	healthz := httpRequestsTotal.WithLabelValues(http.MethodGet, "/api/healthz", "200")
+	before := testutil.ToFloat64(healthz)
+
+	rec := httptest.NewRecorder()
+	server.echoServer.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/api/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, before+1, testutil.ToFloat64(healthz))
+
+	rec = httptest.NewRecorder()
+	server.echoServer.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "wilsonbot_http_requests_total")
+}
+
+func TestBreakagesTotalByRosterMember(t *testing.T) {
+	mockSender := NewMockMessageSender(t)
+	mockSender.On("SendBrokenMessage", mock.Anything, mock.Anything).Return(nil)
+
+	roster := NewInMemoryRosterStorer(TeamMember{Id: "ana", Name: "Ana", Aliases: []string{"ana-gh"}})
+	sender := NewRecordingMessageSender(mockSender, NewInMemoryHistoryStorer(), NewInMemoryBreakageTracker(), roster)
+
+	ana := breakagesTotal.WithLabelValues("ana")
+	unknown := breakagesTotal.WithLabelValues(unknownMember)
+	anaBefore, unknownBefore := testutil.ToFloat64(ana), testutil.ToFloat64(unknown)
+
+	assert.NoError(t, sender.SendBrokenMessage(t.Context(), BrokenMessage{Name: "ana-gh", Motive: "Subiu sem testar"}))
+	assert.NoError(t, sender.SendBrokenMessage(t.Context(), BrokenMessage{Name: "Fulano", Motive: "Subiu sem testar"}))
+
+	assert.Equal(t, anaBefore+1, testutil.ToFloat64(ana))
+	assert.Equal(t, unknownBefore+1, testutil.ToFloat64(unknown))
+}
@@ -0,0 +1,57 @@
+package internal
+
+import (
+	"context"
+	"errors"
+)
+
+// MultiMessageSender fans out every message to all the configured senders
+type MultiMessageSender struct {
+	senders []MessageSender
+}
+
+var (
+	_ MessageSender = (*MultiMessageSender)(nil)
+)
+
+func NewMultiMessageSender(senders ...MessageSender) *MultiMessageSender {
+	return &MultiMessageSender{
+		senders: senders,
+	}
+}
+
+func (m *MultiMessageSender) SendMessage(ctx context.Context, message Message) error {
+	var errs []error
+	for _, sender := range m.senders {
+		errs = append(errs, sender.SendMessage(ctx, message))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (m *MultiMessageSender) SendBrokenMessage(ctx context.Context, message BrokenMessage) error {
+	var errs []error
+	for _, sender := range m.senders {
+		errs = append(errs, sender.SendBrokenMessage(ctx, message))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (m *MultiMessageSender) SendDigest(ctx context.Context, digest Digest) error {
+	var errs []error
+	for _, sender := range m.senders {
+		errs = append(errs, sender.SendDigest(ctx, digest))
+	}
+
+	return errors.Join(errs...)
+}
+
+func (m *MultiMessageSender) SendAlert(ctx context.Context, notification AlertNotification) error {
+	var errs []error
+	for _, sender := range m.senders {
+		errs = append(errs, sender.SendAlert(ctx, notification))
+	}
+
+	return errors.Join(errs...)
+}
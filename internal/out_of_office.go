@@ -0,0 +1,288 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+// where an out of office range came from, the iCal ones are replaced on
+// every import
+const (
+	OutOfOfficeSourceAPI  = "api"
+	OutOfOfficeSourceICal = "ical"
+)
+
+var (
+	ErrOutOfOfficeNotFound = errors.New("out of office not found")
+	ErrInvalidOutOfOffice  = errors.New("out of office takes start and end dates as YYYY-MM-DD, end not before start")
+)
+
+// OutOfOffice is a range of days, both included, the member is away
+type OutOfOffice struct {
+	Id     string `json:"id"`
+	Start  string `json:"start"`
+	End    string `json:"end"`
+	Reason string `json:"reason,omitempty"`
+	Source string `json:"source"`
+}
+
+// Validate checks the dates, they are compared as YYYY-MM-DD strings
+func (o OutOfOffice) Validate() error {
+	start, err := time.Parse(time.DateOnly, o.Start)
+	if err != nil {
+		return ErrInvalidOutOfOffice
+	}
+
+	end, err := time.Parse(time.DateOnly, o.End)
+	if err != nil || end.Before(start) {
+		return ErrInvalidOutOfOffice
+	}
+
+	return nil
+}
+
+// Covers tells if the day is in the range, in the day's own location
+func (o OutOfOffice) Covers(day time.Time) bool {
+	date := day.Format(time.DateOnly)
+
+	return o.Start <= date && date <= o.End
+}
+
+// IsTeamMemberAway looks the person up in the roster, the people out of the
+// roster are never away
+func IsTeamMemberAway(ctx context.Context, roster RosterStorer, name string, day time.Time) bool {
+	member, err := FindTeamMember(ctx, roster, name)
+	if errors.Is(err, ErrTeamMemberNotFound) {
+		return false
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "failed to look up team member", slog.String("name", name), slog.Any("error", err))
+		return false
+	}
+
+	return member.IsAway(day)
+}
+
+// ImportOutOfOffice replaces the iCal ranges of the roster with the events
+// of the calendar, returning how many were matched to a member. See
+// eventMembers for who an event is about
+func ImportOutOfOffice(ctx context.Context, roster RosterStorer, calendar io.Reader) (int, error) {
+	events, err := ParseICal(calendar)
+	if err != nil {
+		return 0, err
+	}
+
+	members, err := roster.GetAllTeamMembers(ctx)
+	if err != nil {
+		return 0, err
+	}
+
+	ranges := make(map[string][]OutOfOffice, len(members))
+
+	imported := 0
+	for _, event := range events {
+		matched := eventMembers(members, event)
+		if len(matched) == 0 {
+			slog.DebugContext(ctx, "skipping calendar event of nobody in the roster", slog.String("summary", event.Summary))
+			continue
+		}
+
+		for _, i := range matched {
+			ranges[members[i].Id] = append(ranges[members[i].Id], OutOfOffice{
+				Id:     uuid.NewString(),
+				Start:  event.Start,
+				End:    event.End,
+				Reason: event.Summary,
+				Source: OutOfOfficeSourceICal,
+			})
+		}
+		imported++
+	}
+
+	// every member is updated on its own, keeping the ranges added through the
+	// API meanwhile. The ones removed meanwhile are just gone
+	for _, member := range members {
+		_, err = roster.UpdateTeamMember(ctx, member.Id, func(member *TeamMember) error {
+			member.OutOfOffice = slices.DeleteFunc(member.OutOfOffice, func(o OutOfOffice) bool {
+				return o.Source == OutOfOfficeSourceICal
+			})
+			member.OutOfOffice = append(member.OutOfOffice, ranges[member.Id]...)
+
+			return nil
+		})
+		if errors.Is(err, ErrTeamMemberNotFound) {
+			continue
+		}
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	return imported, nil
+}
+
+// eventMembers returns the indexes of the members the event is about. The
+// name in the summary, up to the first ":" or " - ", wins. Otherwise it is
+// every attendee in the roster by email, the organizer only counting when
+// there are no attendees, as booking a meeting doesn't make anyone away
+func eventMembers(members []TeamMember, event ICalEvent) []int {
+	name := summaryName(event.Summary)
+
+	i := slices.IndexFunc(members, func(member TeamMember) bool { return member.Matches(name) })
+	if i >= 0 {
+		return []int{i}
+	}
+
+	emails := event.Attendees
+	if len(emails) == 0 && event.Organizer != "" {
+		emails = []string{event.Organizer}
+	}
+
+	var matched []int
+	for i, member := range members {
+		if slices.ContainsFunc(emails, member.Matches) {
+			matched = append(matched, i)
+		}
+	}
+
+	return matched
+}
+
+// ImportOutOfOfficeFile imports the iCal file at path into the roster
+func ImportOutOfOfficeFile(ctx context.Context, roster RosterStorer, path string) error {
+	calendar, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer calendar.Close()
+
+	imported, err := ImportOutOfOffice(ctx, roster, calendar)
+	if err != nil {
+		return err
+	}
+
+	slog.InfoContext(ctx, "imported out of office calendar", slog.String("path", path), slog.Int("events", imported))
+
+	return nil
+}
+
+// ReimportOutOfOffice imports the iCal file again whenever the signal
+// arrives, it is meant for SIGHUP, and every interval when it is set. A
+// failed import keeps the ranges of the last one
+func ReimportOutOfOffice(ctx context.Context, signals <-chan os.Signal, interval time.Duration, roster RosterStorer, path string) {
+	var tick <-chan time.Time
+	if interval > 0 {
+		ticker := time.NewTicker(interval)
+		tick = ticker.C
+
+		context.AfterFunc(ctx, ticker.Stop)
+	}
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signals:
+			case <-tick:
+			}
+
+			err := ImportOutOfOfficeFile(ctx, roster, path)
+			if err != nil {
+				slog.ErrorContext(ctx, "failed to import out of office calendar", slog.String("path", path), slog.Any("error", err))
+			}
+		}
+	}()
+}
+
+// summaryName is who an event like "Ana: Férias" or "Ana - Folga" is about
+func summaryName(summary string) string {
+	name, _, _ := strings.Cut(summary, ":")
+	name, _, _ = strings.Cut(name, " - ")
+
+	return strings.TrimSpace(name)
+}
+
+type outOfOfficeRequest struct {
+	Start  string `json:"start"`
+	End    string `json:"end"`
+	Reason string `json:"reason"`
+}
+
+func (s *Server) AddOutOfOffice(c echo.Context) error {
+	var req outOfOfficeRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(400, map[string]string{"error": "invalid request"})
+	}
+
+	outOfOffice := OutOfOffice{
+		Id:     uuid.NewString(),
+		Start:  req.Start,
+		End:    req.End,
+		Reason: req.Reason,
+		Source: OutOfOfficeSourceAPI,
+	}
+
+	err := outOfOffice.Validate()
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	_, err = s.rosterStorer.UpdateTeamMember(c.Request().Context(), c.Param("id"), func(member *TeamMember) error {
+		member.OutOfOffice = append(member.OutOfOffice, outOfOffice)
+		return nil
+	})
+	if errors.Is(err, ErrTeamMemberNotFound) {
+		return c.JSON(404, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(201, outOfOffice)
+}
+
+func (s *Server) DeleteOutOfOffice(c echo.Context) error {
+	_, err := s.rosterStorer.UpdateTeamMember(c.Request().Context(), c.Param("id"), func(member *TeamMember) error {
+		i := slices.IndexFunc(member.OutOfOffice, func(o OutOfOffice) bool { return o.Id == c.Param("ooo_id") })
+		if i < 0 {
+			return ErrOutOfOfficeNotFound
+		}
+
+		member.OutOfOffice = slices.Delete(member.OutOfOffice, i, i+1)
+
+		return nil
+	})
+	if errors.Is(err, ErrTeamMemberNotFound) || errors.Is(err, ErrOutOfOfficeNotFound) {
+		return c.JSON(404, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	return c.NoContent(204)
+}
+
+// ImportOutOfOfficeCalendar takes the iCal file as the request body
+func (s *Server) ImportOutOfOfficeCalendar(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	imported, err := ImportOutOfOffice(ctx, s.rosterStorer, c.Request().Body)
+	if errors.Is(err, ErrInvalidICal) {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(200, map[string]int{"imported": imported})
+}
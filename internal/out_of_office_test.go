@@ -0,0 +1,253 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTeamMemberIsAway(t *testing.T) {
+	member := TeamMember{OutOfOffice: []OutOfOffice{{Start: "2026-10-19", End: "2026-10-23"}}}
+
+	assert.False(t, member.IsAway(time.Date(2026, 10, 18, 23, 0, 0, 0, time.UTC)))
+	assert.True(t, member.IsAway(time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)))
+	assert.True(t, member.IsAway(time.Date(2026, 10, 23, 23, 0, 0, 0, time.UTC)))
+	assert.False(t, member.IsAway(time.Date(2026, 10, 24, 8, 0, 0, 0, time.UTC)))
+
+	assert.True(t, TeamMember{Away: true}.IsAway(time.Now()))
+}
+
+func TestImportOutOfOffice(t *testing.T) {
+	roster := NewInMemoryRosterStorer(
+		TeamMember{Id: "ana", Name: "Ana", OutOfOffice: []OutOfOffice{
+			{Id: "old", Start: "2026-01-01", End: "2026-01-02", Source: OutOfOfficeSourceICal},
+			{Id: "mine", Start: "2026-12-24", End: "2026-12-31", Source: OutOfOfficeSourceAPI},
+		}},
+		TeamMember{Id: "bruno", Name: "Bruno", Aliases: []string{"bruno@example.com"}},
+		TeamMember{Id: "carla", Name: "Carla", Aliases: []string{"carla@example.com"}},
+	)
+
+	imported, err := ImportOutOfOffice(t.Context(), roster, strings.NewReader(testCalendar))
+	require.NoError(t, err)
+	assert.Equal(t, 3, imported)
+
+	ana, err := roster.GetTeamMemberByID(t.Context(), "ana")
+	require.NoError(t, err)
+	require.Len(t, ana.OutOfOffice, 2)
+	assert.Equal(t, "mine", ana.OutOfOffice[0].Id)
+	assert.Equal(t, "2026-10-19", ana.OutOfOffice[1].Start)
+	assert.Equal(t, "2026-10-23", ana.OutOfOffice[1].End)
+
+	// the organizer of a meeting isn't away, the attendees are
+	bruno, err := roster.GetTeamMemberByID(t.Context(), "bruno")
+	require.NoError(t, err)
+	require.Len(t, bruno.OutOfOffice, 1)
+	assert.Equal(t, OutOfOfficeSourceICal, bruno.OutOfOffice[0].Source)
+	assert.Equal(t, "Dentista", bruno.OutOfOffice[0].Reason)
+
+	carla, err := roster.GetTeamMemberByID(t.Context(), "carla")
+	require.NoError(t, err)
+	require.Len(t, carla.OutOfOffice, 1)
+	assert.Equal(t, "Consulta", carla.OutOfOffice[0].Reason)
+
+	// importing again replaces the ranges instead of piling them up
+	_, err = ImportOutOfOffice(t.Context(), roster, strings.NewReader(testCalendar))
+	require.NoError(t, err)
+
+	ana, err = roster.GetTeamMemberByID(t.Context(), "ana")
+	require.NoError(t, err)
+	assert.Len(t, ana.OutOfOffice, 2)
+}
+
+func TestImportOutOfOfficeSkipsMembersRemovedMeanwhile(t *testing.T) {
+	roster := NewMockRosterStorer(t)
+	roster.On("GetAllTeamMembers", mock.Anything).Return([]TeamMember{{Id: "ana", Name: "Ana"}}, nil)
+	roster.On("UpdateTeamMember", mock.Anything, "ana", mock.Anything).Return(nil, ErrTeamMemberNotFound)
+
+	imported, err := ImportOutOfOffice(t.Context(), roster, strings.NewReader(testCalendar))
+	require.NoError(t, err)
+	assert.Equal(t, 1, imported)
+}
+
+func TestImportOutOfOfficeEveryAttendee(t *testing.T) {
+	roster := NewInMemoryRosterStorer(
+		TeamMember{Id: "ana", Name: "Ana", Aliases: []string{"ana@example.com"}},
+		TeamMember{Id: "bruno", Name: "Bruno", Aliases: []string{"bruno@example.com"}},
+	)
+
+	calendar := "BEGIN:VCALENDAR\r\n" +
+		"BEGIN:VEVENT\r\n" +
+		"SUMMARY:Offsite\r\n" +
+		"DTSTART;VALUE=DATE:20261110\r\n" +
+		"ATTENDEE:mailto:ana@example.com\r\n" +
+		"ATTENDEE:mailto:bruno@example.com\r\n" +
+		"END:VEVENT\r\n" +
+		"END:VCALENDAR\r\n"
+
+	imported, err := ImportOutOfOffice(t.Context(), roster, strings.NewReader(calendar))
+	require.NoError(t, err)
+	assert.Equal(t, 1, imported)
+
+	members, err := roster.GetAllTeamMembers(t.Context())
+	require.NoError(t, err)
+	for _, member := range members {
+		assert.Len(t, member.OutOfOffice, 1, member.Name)
+	}
+}
+
+func TestReimportOutOfOfficeOnSignal(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ooo.ics")
+	require.NoError(t, os.WriteFile(path, []byte(testCalendar), 0o600))
+
+	roster := NewInMemoryRosterStorer(TeamMember{Id: "ana", Name: "Ana"})
+	require.NoError(t, ImportOutOfOfficeFile(t.Context(), roster, path))
+
+	ana, err := roster.GetTeamMemberByID(t.Context(), "ana")
+	require.NoError(t, err)
+	require.Len(t, ana.OutOfOffice, 1)
+
+	signals := make(chan os.Signal, 1)
+	ReimportOutOfOffice(t.Context(), signals, 0, roster, path)
+
+	// the calendar changed, Ana is back
+	require.NoError(t, os.WriteFile(path, []byte("BEGIN:VCALENDAR\r\nEND:VCALENDAR\r\n"), 0o600))
+	signals <- syscall.SIGHUP
+
+	assert.Eventually(t, func() bool {
+		ana, err := roster.GetTeamMemberByID(t.Context(), "ana")
+		return err == nil && len(ana.OutOfOffice) == 0
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestAddOutOfOffice(t *testing.T) {
+	tests := []struct {
+		name         string
+		id           string
+		body         string
+		expectedCode int
+	}{
+		{name: "valid", id: "ana", body: `{"start":"2026-10-19","end":"2026-10-23","reason":"Férias"}`, expectedCode: http.StatusCreated},
+		{name: "single day", id: "ana", body: `{"start":"2026-10-19","end":"2026-10-19"}`, expectedCode: http.StatusCreated},
+		{name: "end before start", id: "ana", body: `{"start":"2026-10-23","end":"2026-10-19"}`, expectedCode: http.StatusBadRequest},
+		{name: "invalid date", id: "ana", body: `{"start":"19/10/2026","end":"2026-10-23"}`, expectedCode: http.StatusBadRequest},
+		{name: "unknown member", id: "bruno", body: `{"start":"2026-10-19","end":"2026-10-23"}`, expectedCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			roster := NewInMemoryRosterStorer(TeamMember{Id: "ana", Name: "Ana"})
+			server := &Server{rosterStorer: roster, echoServer: e}
+
+			req := httptest.NewRequest(http.MethodPost, "/roster/"+tt.id+"/out-of-office", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(tt.id)
+
+			err := server.AddOutOfOffice(c)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rec.Code)
+
+			ana, err := roster.GetTeamMemberByID(t.Context(), "ana")
+			require.NoError(t, err)
+			if tt.expectedCode == http.StatusCreated {
+				assert.Len(t, ana.OutOfOffice, 1)
+			} else {
+				assert.Empty(t, ana.OutOfOffice)
+			}
+		})
+	}
+}
+
+func TestDeleteOutOfOffice(t *testing.T) {
+	e := echo.New()
+	roster := NewInMemoryRosterStorer(TeamMember{Id: "ana", OutOfOffice: []OutOfOffice{{Id: "1", Start: "2026-10-19", End: "2026-10-23"}}})
+	server := &Server{rosterStorer: roster, echoServer: e}
+
+	for _, expectedCode := range []int{http.StatusNoContent, http.StatusNotFound} {
+		req := httptest.NewRequest(http.MethodDelete, "/roster/ana/out-of-office/1", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id", "ooo_id")
+		c.SetParamValues("ana", "1")
+
+		err := server.DeleteOutOfOffice(c)
+		require.NoError(t, err)
+		assert.Equal(t, expectedCode, rec.Code)
+	}
+}
+
+func TestImportOutOfOfficeCalendar(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		expectedCode int
+	}{
+		{name: "valid", body: testCalendar, expectedCode: http.StatusOK},
+		{name: "not a calendar", body: "hello", expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			server := &Server{rosterStorer: NewInMemoryRosterStorer(TeamMember{Id: "ana", Name: "Ana"}), echoServer: e}
+
+			req := httptest.NewRequest(http.MethodPost, "/roster/out-of-office/import", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, "text/calendar")
+			rec := httptest.NewRecorder()
+
+			err := server.ImportOutOfOfficeCalendar(e.NewContext(req, rec))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rec.Code)
+
+			if tt.expectedCode == http.StatusOK {
+				var resp map[string]int
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, 1, resp["imported"])
+			}
+		})
+	}
+}
+
+func TestMentionMessageSenderSkipsMembersAway(t *testing.T) {
+	today := time.Now().Format(time.DateOnly)
+	roster := NewInMemoryRosterStorer(TeamMember{Id: "ana", Name: "Ana", DiscordID: "123", OutOfOffice: []OutOfOffice{{Start: today, End: today}}})
+
+	mockSender := NewMockMessageSender(t)
+	mockSender.On("SendBrokenMessage", mock.MatchedBy(func(ctx context.Context) bool {
+		return len(Mentions(ctx)) == 0
+	}), BrokenMessage{Name: "Ana"}).Return(nil)
+
+	err := NewMentionMessageSender(mockSender, roster).SendBrokenMessage(t.Context(), BrokenMessage{Name: "Ana"})
+	assert.NoError(t, err)
+}
+
+func TestRotationSkipsOutOfOffice(t *testing.T) {
+	roster := NewInMemoryRosterStorer(
+		TeamMember{Id: "ana", OutOfOffice: []OutOfOffice{{Start: "2026-10-19", End: "2026-10-23"}}},
+		TeamMember{Id: "bruno"},
+	)
+	rotation := NewRotation(roster, NewInMemoryRotationStorer())
+
+	member, err := rotation.Next(t.Context(), time.Date(2026, 10, 20, 8, 0, 0, 0, time.Local))
+	require.NoError(t, err)
+	assert.Equal(t, "bruno", member.Id)
+
+	member, err = rotation.Next(t.Context(), time.Date(2026, 10, 26, 8, 0, 0, 0, time.Local))
+	require.NoError(t, err)
+	assert.Equal(t, "ana", member.Id)
+}
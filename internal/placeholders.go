@@ -0,0 +1,171 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"maps"
+	"regexp"
+	"strconv"
+	"time"
+)
+
+var (
+	ErrMissingVariable = errors.New("missing message variable")
+)
+
+// placeholderPattern only matches plain variable names, there is no logic in
+// a message
+var placeholderPattern = regexp.MustCompile(`\{\{\s*([a-z_][a-z0-9_]*)\s*\}\}`)
+
+var weekdays = [...]string{
+	time.Sunday:    "domingo",
+	time.Monday:    "segunda-feira",
+	time.Tuesday:   "terça-feira",
+	time.Wednesday: "quarta-feira",
+	time.Thursday:  "quinta-feira",
+	time.Friday:    "sexta-feira",
+	time.Saturday:  "sábado",
+}
+
+// builtinVariables are available to every message, the vars of the send
+// take precedence over them
+func builtinVariables(now time.Time) map[string]string {
+	return map[string]string{
+		"weekday":           weekdays[now.Weekday()],
+		"date":              now.Format("02/01/2006"),
+		"days_until_friday": strconv.Itoa((int(time.Friday) - int(now.Weekday()) + 7) % 7),
+	}
+}
+
+// RenderPlaceholders replaces the {{variable}} placeholders of the text,
+// failing when a variable has no value
+func RenderPlaceholders(text string, vars map[string]string, now time.Time) (string, error) {
+	values := builtinVariables(now)
+	maps.Copy(values, vars)
+
+	var missing []string
+	rendered := placeholderPattern.ReplaceAllStringFunc(text, func(placeholder string) string {
+		name := placeholderPattern.FindStringSubmatch(placeholder)[1]
+
+		value, ok := values[name]
+		if !ok {
+			missing = append(missing, name)
+			return placeholder
+		}
+
+		return value
+	})
+
+	if len(missing) > 0 {
+		return "", fmt.Errorf("%w: %v", ErrMissingVariable, missing)
+	}
+
+	return rendered, nil
+}
+
+// WithPlaceholderDefaults fills the variables the vars leave empty with the
+// configured defaults
+func WithPlaceholderDefaults(defaults map[string]string, vars map[string]string) map[string]string {
+	merged := maps.Clone(defaults)
+	if merged == nil {
+		merged = make(map[string]string)
+	}
+
+	for name, value := range vars {
+		if value != "" {
+			merged[name] = value
+		}
+	}
+
+	return merged
+}
+
+// RenderMessagePlaceholders fills the placeholders of a message picked
+// outside the send pipeline, as the command replies do, keeping the text as
+// is when a variable is missing
+func RenderMessagePlaceholders(ctx context.Context, message Message, defaults map[string]string, vars map[string]string) Message {
+	rendered, err := RenderPlaceholders(message.Message, WithPlaceholderDefaults(defaults, vars), time.Now())
+	if err != nil {
+		slog.WarnContext(ctx, "failed to render message placeholders", slog.String("message_id", message.Id), slog.Any("error", err))
+		return message
+	}
+
+	message.Message = rendered
+
+	return message
+}
+
+type messageVarsKey struct{}
+
+// WithMessageVars sets the variables of the messages sent with the context
+func WithMessageVars(ctx context.Context, vars map[string]string) context.Context {
+	if len(vars) == 0 {
+		return ctx
+	}
+
+	return context.WithValue(ctx, messageVarsKey{}, vars)
+}
+
+func messageVars(ctx context.Context) map[string]string {
+	vars, _ := ctx.Value(messageVarsKey{}).(map[string]string)
+
+	return vars
+}
+
+// PlaceholderMessageSender decorates a MessageSender rendering the
+// placeholders of the messages before they reach the card templates
+type PlaceholderMessageSender struct {
+	next     MessageSender
+	defaults map[string]string
+}
+
+var (
+	_ MessageSender = (*PlaceholderMessageSender)(nil)
+)
+
+// NewPlaceholderMessageSender creates the decorator, defaults are used for
+// the variables the send doesn't set
+func NewPlaceholderMessageSender(next MessageSender, defaults map[string]string) *PlaceholderMessageSender {
+	return &PlaceholderMessageSender{
+		next:     next,
+		defaults: defaults,
+	}
+}
+
+func (s *PlaceholderMessageSender) SendMessage(ctx context.Context, message Message) error {
+	vars := maps.Clone(s.defaults)
+	if vars == nil {
+		vars = make(map[string]string)
+	}
+
+	maps.Copy(vars, messageVars(ctx))
+
+	rendered, err := RenderPlaceholders(message.Message, vars, time.Now())
+	if err != nil {
+		return err
+	}
+
+	message.Message = rendered
+
+	return s.next.SendMessage(ctx, message)
+}
+
+func (s *PlaceholderMessageSender) SendBrokenMessage(ctx context.Context, message BrokenMessage) error {
+	return s.next.SendBrokenMessage(ctx, message)
+}
+
+func (s *PlaceholderMessageSender) SendDigest(ctx context.Context, digest Digest) error {
+	return s.next.SendDigest(ctx, digest)
+}
+
+func (s *PlaceholderMessageSender) SendAlert(ctx context.Context, notification AlertNotification) error {
+	return s.next.SendAlert(ctx, notification)
+}
+
+// SetPlaceholderDefaults sets the default values of the variables for the
+// messages picked outside the send pipeline, as the alert roast
+func (s *Server) SetPlaceholderDefaults(defaults map[string]string) {
+	s.placeholders = defaults
+}
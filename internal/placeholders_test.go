@@ -0,0 +1,148 @@
+package internal
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRenderPlaceholders(t *testing.T) {
+	wednesday := time.Date(2026, time.October, 14, 9, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		text     string
+		vars     map[string]string
+		now      time.Time
+		expected string
+		err      error
+	}{
+		{
+			name:     "builtins",
+			text:     "Hoje é {{weekday}}, {{date}}, faltam {{ days_until_friday }} dias para sexta",
+			now:      wednesday,
+			expected: "Hoje é quarta-feira, 14/10/2026, faltam 2 dias para sexta",
+		},
+		{
+			name:     "friday",
+			text:     "{{days_until_friday}}",
+			now:      wednesday.AddDate(0, 0, 2),
+			expected: "0",
+		},
+		{
+			name:     "saturday",
+			text:     "{{days_until_friday}}",
+			now:      wednesday.AddDate(0, 0, 3),
+			expected: "6",
+		},
+		{
+			name:     "vars",
+			text:     "{{name}}, tá em qual semestre da faculdade?",
+			vars:     map[string]string{"name": "Wilson"},
+			expected: "Wilson, tá em qual semestre da faculdade?",
+		},
+		{
+			name:     "values aren't rendered again",
+			text:     "{{name}}",
+			vars:     map[string]string{"name": "{{date}}"},
+			expected: "{{date}}",
+		},
+		{
+			name:     "not a placeholder",
+			text:     "{{ .Message }} {name}",
+			expected: "{{ .Message }} {name}",
+		},
+		{
+			name: "missing variable",
+			text: "{{name}}, tá em qual semestre da faculdade?",
+			err:  ErrMissingVariable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rendered, err := RenderPlaceholders(tt.text, tt.vars, tt.now)
+			if tt.err != nil {
+				assert.ErrorIs(t, err, tt.err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, rendered)
+		})
+	}
+}
+
+func TestPlaceholderMessageSender(t *testing.T) {
+	mockSender := NewMockMessageSender(t)
+	mockSender.On("SendMessage", mock.Anything, Message{Id: "1", Message: "Joana, bom dia"}).Return(nil)
+
+	sender := NewPlaceholderMessageSender(mockSender, map[string]string{"name": "Wilson", "greeting": "bom dia"})
+
+	ctx := WithMessageVars(t.Context(), map[string]string{"name": "Joana"})
+	err := sender.SendMessage(ctx, Message{Id: "1", Message: "{{name}}, {{greeting}}"})
+	assert.NoError(t, err)
+}
+
+func TestSendMessageByIdWithVars(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		expectedCode int
+	}{
+		{name: "vars", body: `{"vars":{"name":"Joana"}}`, expectedCode: http.StatusOK},
+		{name: "missing variable", body: `{"vars":{"nome":"Joana"}}`, expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mockStore := NewMockMessageStorer(t)
+			mockStore.On("GetMessageByID", mock.Anything, "1").Return(&Message{Id: "1", Message: "{{name}}, tá em qual semestre da faculdade?"}, nil)
+
+			mockSender := NewMockMessageSender(t)
+			if tt.expectedCode == http.StatusOK {
+				mockSender.On("SendMessage", mock.Anything, Message{Id: "1", Message: "Joana, tá em qual semestre da faculdade?"}).Return(nil)
+			}
+
+			e := echo.New()
+			server := &Server{
+				messageStorer: mockStore,
+				messageSender: NewPlaceholderMessageSender(mockSender, nil),
+				sendMessages:  true,
+				echoServer:    e,
+			}
+
+			req := httptest.NewRequest(http.MethodPost, "/messages/1", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues("1")
+
+			err := server.SendMessageById(c)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rec.Code)
+		})
+	}
+}
+
+func TestRenderMessagePlaceholders(t *testing.T) {
+	message := Message{Id: "1", Message: "{{name}}, bora?"}
+
+	rendered := RenderMessagePlaceholders(t.Context(), message, nil, map[string]string{"name": "Ana"})
+	assert.Equal(t, "Ana, bora?", rendered.Message)
+
+	rendered = RenderMessagePlaceholders(t.Context(), message, nil, nil)
+	assert.Equal(t, message, rendered)
+
+	// an empty user name falls back to the default
+	rendered = RenderMessagePlaceholders(t.Context(), message, map[string]string{"name": "pessoal"}, map[string]string{"name": ""})
+	assert.Equal(t, "pessoal, bora?", rendered.Message)
+}
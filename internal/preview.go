@@ -0,0 +1,136 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	ErrUnsupportedContent = errors.New("content not supported by the renderer")
+)
+
+// Renderer renders the exact body a platform receives for a Message,
+// BrokenMessage, Digest or AlertNotification, without delivering it
+type Renderer interface {
+	Render(ctx context.Context, content any) ([]byte, error)
+}
+
+// AddRenderer makes the platform available to the preview endpoints
+func (s *Server) AddRenderer(platform string, renderer Renderer) {
+	if s.renderers == nil {
+		s.renderers = make(map[string]Renderer)
+	}
+
+	s.renderers[platform] = renderer
+}
+
+// previewMessageSender ends the message pipeline rendering the payload
+// instead of delivering it
+type previewMessageSender struct {
+	renderer Renderer
+	payload  []byte
+}
+
+var (
+	_ MessageSender = (*previewMessageSender)(nil)
+)
+
+func (s *previewMessageSender) SendMessage(ctx context.Context, message Message) error {
+	return s.render(ctx, message)
+}
+
+func (s *previewMessageSender) SendBrokenMessage(ctx context.Context, message BrokenMessage) error {
+	return s.render(ctx, message)
+}
+
+func (s *previewMessageSender) SendDigest(ctx context.Context, digest Digest) error {
+	return s.render(ctx, digest)
+}
+
+func (s *previewMessageSender) SendAlert(ctx context.Context, notification AlertNotification) error {
+	return s.render(ctx, notification)
+}
+
+func (s *previewMessageSender) render(ctx context.Context, content any) (err error) {
+	s.payload, err = s.renderer.Render(ctx, content)
+	return err
+}
+
+// renderPreview answers with the body rendered by the platform renderer
+// chosen in the query string, the content goes through the same decorators
+// as a send
+func (s *Server) renderPreview(c echo.Context, ctx context.Context, content any) error {
+	platform := c.QueryParam("platform")
+
+	renderer, ok := s.renderers[platform]
+	if !ok {
+		var platforms []string
+		for p := range s.renderers {
+			platforms = append(platforms, p)
+		}
+		slices.Sort(platforms)
+
+		return c.JSON(400, map[string]string{"error": "unknown platform, expected one of: " + strings.Join(platforms, ", ")})
+	}
+
+	preview := &previewMessageSender{renderer: renderer}
+	sender := s.throughPipeline(preview)
+
+	var err error
+	switch content := content.(type) {
+	case Message:
+		err = sender.SendMessage(ctx, content)
+	case BrokenMessage:
+		err = sender.SendBrokenMessage(ctx, content)
+	case Digest:
+		err = sender.SendDigest(ctx, content)
+	case AlertNotification:
+		err = sender.SendAlert(ctx, content)
+	default:
+		err = ErrUnsupportedContent
+	}
+
+	if errors.Is(err, ErrUnsupportedContent) || errors.Is(err, ErrMissingVariable) {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSONBlob(200, preview.payload)
+}
+
+// PreviewMessage takes the same optional vars and target as a send
+func (s *Server) PreviewMessage(c echo.Context) error {
+	var req sendMessageRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(400, map[string]string{"error": "invalid request"})
+	}
+
+	ctx := s.sendContext(c.Request().Context(), req)
+
+	message, err := s.messageStorer.GetMessageByID(ctx, c.Param("id"))
+	if errors.Is(err, ErrMessageNotFound) {
+		return c.JSON(404, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	return s.renderPreview(c, ctx, *message)
+}
+
+// PreviewBrokenMessage renders the broken message webhook body, nothing is
+// recorded in the breakage tracker
+func (s *Server) PreviewBrokenMessage(c echo.Context) error {
+	var brokenMessage BrokenMessage
+	if err := c.Bind(&brokenMessage); err != nil {
+		return c.JSON(400, map[string]string{"error": "invalid request"})
+	}
+
+	return s.renderPreview(c, c.Request().Context(), brokenMessage)
+}
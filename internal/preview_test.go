@@ -0,0 +1,123 @@
+package internal
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPreviewMessageMatchesDeliveredBody(t *testing.T) {
+	var delivered []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		delivered, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	sender, err := NewHardcodedGoogleChatProvider(srv.URL, mustGoogleChatTemplates(t), GoogleChatThreadsConfig{}, LimitModeTruncate)
+	require.NoError(t, err)
+
+	message := Message{Id: "1", Message: "Obrigado, Wilson"}
+	require.NoError(t, sender.SendMessage(t.Context(), message))
+
+	e := echo.New()
+	mockStore := NewMockMessageStorer(t)
+	mockStore.On("GetMessageByID", mock.Anything, "1").Return(&message, nil)
+
+	server := &Server{messageStorer: mockStore, echoServer: e}
+	server.AddRenderer("googlechat", sender)
+
+	req := httptest.NewRequest(http.MethodGet, "/messages/1/preview?platform=googlechat", nil)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+
+	err = server.PreviewMessage(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, string(delivered), rec.Body.String())
+}
+
+func TestPreviewBrokenMessageUnknownPlatform(t *testing.T) {
+	e := echo.New()
+	sender, err := NewHardcodedGoogleChatProvider("", mustGoogleChatTemplates(t), GoogleChatThreadsConfig{}, LimitModeTruncate)
+	require.NoError(t, err)
+
+	server := &Server{echoServer: e}
+	server.AddRenderer("googlechat", sender)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook/broken/preview?platform=teams", strings.NewReader(`{"name": "Wilson", "motive": "Subiu sem testar"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	err = server.PreviewBrokenMessage(e.NewContext(req, rec))
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "googlechat")
+}
+
+func TestPreviewBrokenMessage(t *testing.T) {
+	e := echo.New()
+	sender, err := NewHardcodedGoogleChatProvider("", mustGoogleChatTemplates(t), GoogleChatThreadsConfig{}, LimitModeTruncate)
+	require.NoError(t, err)
+
+	server := &Server{echoServer: e}
+	server.AddRenderer("googlechat", sender)
+
+	req := httptest.NewRequest(http.MethodPost, "/webhook/broken/preview?platform=googlechat", strings.NewReader(`{"name": "Wilson", "motive": "Subiu sem testar"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+
+	err = server.PreviewBrokenMessage(e.NewContext(req, rec))
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Subiu sem testar")
+}
+
+func TestPreviewMessageWithVarsAndTarget(t *testing.T) {
+	sender, err := NewHardcodedGoogleChatProvider("", mustGoogleChatTemplates(t), GoogleChatThreadsConfig{}, LimitModeTruncate)
+	require.NoError(t, err)
+
+	e := echo.New()
+	mockStore := NewMockMessageStorer(t)
+	mockStore.On("GetMessageByID", mock.Anything, "1").Return(&Message{Id: "1", Message: "{{name}}, bora {{place}}?"}, nil)
+
+	roster := NewInMemoryRosterStorer(TeamMember{Id: "ana", Name: "Ana", GoogleChatID: "456"})
+
+	server := &Server{messageStorer: mockStore, rosterStorer: roster, echoServer: e}
+	server.AddRenderer("googlechat", sender)
+	server.SetMessagePipeline(func(next MessageSender) MessageSender {
+		return NewMentionMessageSender(NewPlaceholderMessageSender(next, nil), roster)
+	})
+
+	preview := func(body string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodPost, "/messages/1/preview?platform=googlechat", strings.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		require.NoError(t, server.PreviewMessage(c))
+		return rec
+	}
+
+	rec := preview(`{"target":"ana","vars":{"place":"almoçar"}}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Ana, bora almoçar?")
+	assert.Contains(t, rec.Body.String(), `\u003cusers/456\u003e`)
+	assert.NotContains(t, rec.Body.String(), "{{")
+
+	rec = preview(`{"target":"ana"}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
@@ -0,0 +1,538 @@
+package internal
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	ErrTeamMemberNotFound = errors.New("team member not found")
+	ErrInvalidPlatformID  = errors.New("invalid platform user id")
+)
+
+var (
+	// googleChatIDPattern is the numeric user id, with or without the users/
+	// prefix of the resource name
+	googleChatIDPattern = regexp.MustCompile(`^(users/)?[0-9]+$`)
+	// slackIDPattern covers the user ids and the enterprise grid W ids
+	slackIDPattern = regexp.MustCompile(`^[UW][A-Z0-9]+$`)
+)
+
+// TeamMember maps a person to their user on every platform, so the cards
+// about them can mention them
+type TeamMember struct {
+	Id      string   `json:"id"`
+	Name    string   `json:"name"`
+	Aliases []string `json:"aliases"`
+
+	DiscordID    string `json:"discord_id,omitempty"`
+	GoogleChatID string `json:"google_chat_id,omitempty"`
+	SlackID      string `json:"slack_id,omitempty"`
+
+	// Away marks the member on vacation until it is cleared, OutOfOffice
+	// holds the planned absences
+	Away        bool          `json:"away"`
+	OutOfOffice []OutOfOffice `json:"out_of_office"`
+
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// IsAway tells if the member is out of office on the day
+func (m TeamMember) IsAway(day time.Time) bool {
+	return m.Away || slices.ContainsFunc(m.OutOfOffice, func(o OutOfOffice) bool {
+		return o.Covers(day)
+	})
+}
+
+// Matches compares the name and the aliases ignoring case
+func (m TeamMember) Matches(name string) bool {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return false
+	}
+
+	return strings.EqualFold(m.Name, name) || slices.ContainsFunc(m.Aliases, func(alias string) bool {
+		return strings.EqualFold(alias, name)
+	})
+}
+
+// DiscordMention is empty when the member has no Discord user
+func (m TeamMember) DiscordMention() string {
+	if m.DiscordID == "" {
+		return ""
+	}
+
+	return "<@" + m.DiscordID + ">"
+}
+
+// GoogleChatMention accepts the id with or without the users/ prefix of the
+// resource name
+func (m TeamMember) GoogleChatMention() string {
+	if m.GoogleChatID == "" {
+		return ""
+	}
+
+	return "<users/" + strings.TrimPrefix(m.GoogleChatID, "users/") + ">"
+}
+
+// SlackMention is empty when the member has no Slack user
+func (m TeamMember) SlackMention() string {
+	if m.SlackID == "" {
+		return ""
+	}
+
+	return "<@" + m.SlackID + ">"
+}
+
+type RosterStorer interface {
+	AddTeamMember(ctx context.Context, member TeamMember) error
+	GetAllTeamMembers(ctx context.Context) ([]TeamMember, error)
+	GetTeamMemberByID(ctx context.Context, id string) (*TeamMember, error)
+	// UpdateTeamMember applies update to the member atomically, nothing is
+	// changed when update fails
+	UpdateTeamMember(ctx context.Context, id string, update func(member *TeamMember) error) (*TeamMember, error)
+	DeleteTeamMember(ctx context.Context, id string) error
+}
+
+type InMemoryRosterStorer struct {
+	mu      sync.RWMutex
+	members []TeamMember
+}
+
+var (
+	_ RosterStorer = (*InMemoryRosterStorer)(nil)
+)
+
+func NewInMemoryRosterStorer(members ...TeamMember) *InMemoryRosterStorer {
+	return &InMemoryRosterStorer{
+		members: members,
+	}
+}
+
+func (s *InMemoryRosterStorer) AddTeamMember(ctx context.Context, member TeamMember) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.members = append(s.members, member)
+
+	return nil
+}
+
+func (s *InMemoryRosterStorer) GetAllTeamMembers(ctx context.Context) ([]TeamMember, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.members), nil
+}
+
+func (s *InMemoryRosterStorer) GetTeamMemberByID(ctx context.Context, id string) (*TeamMember, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	for _, member := range s.members {
+		if member.Id == id {
+			return &member, nil
+		}
+	}
+
+	return nil, ErrTeamMemberNotFound
+}
+
+func (s *InMemoryRosterStorer) UpdateTeamMember(ctx context.Context, id string, update func(member *TeamMember) error) (*TeamMember, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.members, func(member TeamMember) bool { return member.Id == id })
+	if i < 0 {
+		return nil, ErrTeamMemberNotFound
+	}
+
+	// updated on a copy, the slices are cloned as update may append to them
+	member := s.members[i]
+	member.Aliases = slices.Clone(member.Aliases)
+	member.OutOfOffice = slices.Clone(member.OutOfOffice)
+
+	err := update(&member)
+	if err != nil {
+		return nil, err
+	}
+
+	s.members[i] = member
+
+	return &member, nil
+}
+
+func (s *InMemoryRosterStorer) DeleteTeamMember(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	i := slices.IndexFunc(s.members, func(member TeamMember) bool { return member.Id == id })
+	if i < 0 {
+		return ErrTeamMemberNotFound
+	}
+
+	s.members = slices.Delete(s.members, i, i+1)
+
+	return nil
+}
+
+// FindTeamMember looks the member up by id, name or alias
+func FindTeamMember(ctx context.Context, storer RosterStorer, name string) (*TeamMember, error) {
+	if strings.TrimSpace(name) == "" {
+		return nil, ErrTeamMemberNotFound
+	}
+
+	members, err := storer.GetAllTeamMembers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, member := range members {
+		if member.Id == name || member.Matches(name) {
+			return &member, nil
+		}
+	}
+
+	return nil, ErrTeamMemberNotFound
+}
+
+type mentionsKey struct{}
+
+// WithMentions makes the senders mention the members in the cards sent with
+// the context
+func WithMentions(ctx context.Context, members ...TeamMember) context.Context {
+	if len(members) == 0 {
+		return ctx
+	}
+
+	return context.WithValue(ctx, mentionsKey{}, slices.Concat(Mentions(ctx), members))
+}
+
+// Mentions returns the members to mention in the cards sent with the context
+func Mentions(ctx context.Context) []TeamMember {
+	members, _ := ctx.Value(mentionsKey{}).([]TeamMember)
+
+	return members
+}
+
+type messageTargetKey struct{}
+
+// WithMessageTarget sets who the messages sent with the context are meant
+// for, by id, name or alias of the roster
+func WithMessageTarget(ctx context.Context, target string) context.Context {
+	if target == "" {
+		return ctx
+	}
+
+	return context.WithValue(ctx, messageTargetKey{}, target)
+}
+
+func messageTarget(ctx context.Context) string {
+	target, _ := ctx.Value(messageTargetKey{}).(string)
+
+	return target
+}
+
+// MentionMessageSender decorates a MessageSender looking up the people the
+// cards are about in the roster, the platforms then mention them
+type MentionMessageSender struct {
+	next   MessageSender
+	roster RosterStorer
+}
+
+var (
+	_ MessageSender = (*MentionMessageSender)(nil)
+)
+
+func NewMentionMessageSender(next MessageSender, roster RosterStorer) *MentionMessageSender {
+	return &MentionMessageSender{
+		next:   next,
+		roster: roster,
+	}
+}
+
+func (s *MentionMessageSender) SendMessage(ctx context.Context, message Message) error {
+	return s.next.SendMessage(s.mention(ctx, messageTarget(ctx)), message)
+}
+
+func (s *MentionMessageSender) SendBrokenMessage(ctx context.Context, message BrokenMessage) error {
+	return s.next.SendBrokenMessage(s.mention(ctx, message.Name), message)
+}
+
+func (s *MentionMessageSender) SendDigest(ctx context.Context, digest Digest) error {
+	return s.next.SendDigest(ctx, digest)
+}
+
+func (s *MentionMessageSender) SendAlert(ctx context.Context, notification AlertNotification) error {
+	return s.next.SendAlert(s.mention(ctx, notification.OnCall), notification)
+}
+
+// mention never fails the send, the card just goes without the mention when
+// the person isn't in the roster or is away
+func (s *MentionMessageSender) mention(ctx context.Context, name string) context.Context {
+	if strings.TrimSpace(name) == "" {
+		return ctx
+	}
+
+	member, err := FindTeamMember(ctx, s.roster, name)
+	if errors.Is(err, ErrTeamMemberNotFound) {
+		return ctx
+	}
+	if err != nil {
+		slog.WarnContext(ctx, "failed to look up team member", slog.String("name", name), slog.Any("error", err))
+		return ctx
+	}
+
+	// nobody wants to be pinged on vacation
+	if member.IsAway(time.Now()) {
+		return ctx
+	}
+
+	return WithMentions(ctx, *member)
+}
+
+type teamMemberRequest struct {
+	Name         string   `json:"name"`
+	Aliases      []string `json:"aliases"`
+	DiscordID    string   `json:"discord_id"`
+	GoogleChatID string   `json:"google_chat_id"`
+	SlackID      string   `json:"slack_id"`
+	Away         bool     `json:"away"`
+}
+
+// NewTeamMember creates a member of the roster seed with the checks of the
+// API, the id is generated when the seed has none
+func NewTeamMember(cfg TeamMemberConfig, now time.Time) (TeamMember, error) {
+	if strings.TrimSpace(cfg.Name) == "" {
+		return TeamMember{}, errors.New("team member name is required")
+	}
+
+	err := validatePlatformIDs(cfg.DiscordID, cfg.GoogleChatID, cfg.SlackID)
+	if err != nil {
+		return TeamMember{}, fmt.Errorf("team member %s: %w", cfg.Name, err)
+	}
+
+	return TeamMember{
+		Id:           cmp.Or(strings.TrimSpace(cfg.Id), uuid.NewString()),
+		Name:         strings.TrimSpace(cfg.Name),
+		Aliases:      cfg.Aliases,
+		DiscordID:    cfg.DiscordID,
+		GoogleChatID: cfg.GoogleChatID,
+		SlackID:      cfg.SlackID,
+		Away:         cfg.Away,
+		CreatedAt:    now,
+	}, nil
+}
+
+// validatePlatformIDs checks the platform users of a member, an empty id means
+// the member isn't on the platform
+func validatePlatformIDs(discordID string, googleChatID string, slackID string) error {
+	// Discord ids are snowflakes, unsigned 64 bit numbers
+	if _, err := strconv.ParseUint(discordID, 10, 64); discordID != "" && err != nil {
+		return fmt.Errorf("%w: discord_id must be a numeric snowflake", ErrInvalidPlatformID)
+	}
+
+	if googleChatID != "" && !googleChatIDPattern.MatchString(googleChatID) {
+		return fmt.Errorf("%w: google_chat_id must be a numeric user id, optionally prefixed by users/", ErrInvalidPlatformID)
+	}
+
+	if slackID != "" && !slackIDPattern.MatchString(slackID) {
+		return fmt.Errorf("%w: slack_id must be a user id as U0123ABCD", ErrInvalidPlatformID)
+	}
+
+	return nil
+}
+
+// takenName finds the first of the names already used by a member other than
+// the one with exceptID
+func (s *Server) takenName(ctx context.Context, names []string, exceptID string) (string, error) {
+	for _, name := range names {
+		member, err := FindTeamMember(ctx, s.rosterStorer, name)
+		if errors.Is(err, ErrTeamMemberNotFound) {
+			continue
+		}
+		if err != nil {
+			return "", err
+		}
+
+		if member.Id != exceptID {
+			return name, nil
+		}
+	}
+
+	return "", nil
+}
+
+func (s *Server) GetAllTeamMembers(c echo.Context) error {
+	members, err := s.rosterStorer.GetAllTeamMembers(c.Request().Context())
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(200, members)
+}
+
+func (s *Server) CreateTeamMember(c echo.Context) error {
+	var req teamMemberRequest
+	if err := c.Bind(&req); err != nil {
+		return c.JSON(400, map[string]string{"error": "invalid request"})
+	}
+
+	if strings.TrimSpace(req.Name) == "" {
+		return c.JSON(400, map[string]string{"error": "name is required"})
+	}
+
+	err := validatePlatformIDs(req.DiscordID, req.GoogleChatID, req.SlackID)
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	ctx := c.Request().Context()
+
+	taken, err := s.takenName(ctx, append([]string{req.Name}, req.Aliases...), "")
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+	if taken != "" {
+		return c.JSON(409, map[string]string{"error": "name already in the roster: " + taken})
+	}
+
+	member := TeamMember{
+		Id:           uuid.NewString(),
+		Name:         strings.TrimSpace(req.Name),
+		Aliases:      req.Aliases,
+		DiscordID:    req.DiscordID,
+		GoogleChatID: req.GoogleChatID,
+		SlackID:      req.SlackID,
+		Away:         req.Away,
+		CreatedAt:    time.Now(),
+	}
+
+	err = s.rosterStorer.AddTeamMember(ctx, member)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(201, member)
+}
+
+// updateTeamMemberRequest only changes the fields it sets, an empty platform
+// id removes the member from the platform
+type updateTeamMemberRequest struct {
+	Name         *string   `json:"name"`
+	Aliases      *[]string `json:"aliases"`
+	DiscordID    *string   `json:"discord_id"`
+	GoogleChatID *string   `json:"google_chat_id"`
+	SlackID      *string   `json:"slack_id"`
+	Away         *bool     `json:"away"`
+}
+
+func (r updateTeamMemberRequest) empty() bool {
+	return r.Name == nil && r.Aliases == nil && r.DiscordID == nil && r.GoogleChatID == nil && r.SlackID == nil && r.Away == nil
+}
+
+// names are the names the update gives to the member
+func (r updateTeamMemberRequest) names() []string {
+	var names []string
+	if r.Name != nil {
+		names = append(names, *r.Name)
+	}
+	if r.Aliases != nil {
+		names = append(names, *r.Aliases...)
+	}
+
+	return names
+}
+
+// stringValue is empty for the fields missing in the request
+func stringValue(s *string) string {
+	if s == nil {
+		return ""
+	}
+
+	return *s
+}
+
+// UpdateTeamMember partially updates the member, renaming them, changing
+// their platform users or marking them away or back
+func (s *Server) UpdateTeamMember(c echo.Context) error {
+	var req updateTeamMemberRequest
+	if err := c.Bind(&req); err != nil || req.empty() {
+		return c.JSON(400, map[string]string{"error": "invalid request"})
+	}
+
+	if req.Name != nil && strings.TrimSpace(*req.Name) == "" {
+		return c.JSON(400, map[string]string{"error": "name can't be empty"})
+	}
+
+	err := validatePlatformIDs(stringValue(req.DiscordID), stringValue(req.GoogleChatID), stringValue(req.SlackID))
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": err.Error()})
+	}
+
+	ctx := c.Request().Context()
+	id := c.Param("id")
+
+	taken, err := s.takenName(ctx, req.names(), id)
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+	if taken != "" {
+		return c.JSON(409, map[string]string{"error": "name already in the roster: " + taken})
+	}
+
+	member, err := s.rosterStorer.UpdateTeamMember(ctx, id, func(member *TeamMember) error {
+		if req.Name != nil {
+			member.Name = strings.TrimSpace(*req.Name)
+		}
+		if req.Aliases != nil {
+			member.Aliases = *req.Aliases
+		}
+		if req.DiscordID != nil {
+			member.DiscordID = *req.DiscordID
+		}
+		if req.GoogleChatID != nil {
+			member.GoogleChatID = *req.GoogleChatID
+		}
+		if req.SlackID != nil {
+			member.SlackID = *req.SlackID
+		}
+		if req.Away != nil {
+			member.Away = *req.Away
+		}
+
+		return nil
+	})
+	if errors.Is(err, ErrTeamMemberNotFound) {
+		return c.JSON(404, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(200, member)
+}
+
+func (s *Server) DeleteTeamMember(c echo.Context) error {
+	err := s.rosterStorer.DeleteTeamMember(c.Request().Context(), c.Param("id"))
+	if errors.Is(err, ErrTeamMemberNotFound) {
+		return c.JSON(404, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	return c.NoContent(204)
+}
@@ -0,0 +1,241 @@
+package internal
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFindTeamMember(t *testing.T) {
+	storer := NewInMemoryRosterStorer(
+		TeamMember{Id: "1", Name: "Ana", Aliases: []string{"ana-gh", "ana@example.com"}},
+		TeamMember{Id: "2", Name: "Bruno"},
+	)
+
+	for _, name := range []string{"1", "ana", " Ana ", "ANA-GH", "ana@example.com"} {
+		member, err := FindTeamMember(t.Context(), storer, name)
+		require.NoError(t, err, name)
+		assert.Equal(t, "1", member.Id)
+	}
+
+	_, err := FindTeamMember(t.Context(), storer, "Carla")
+	assert.ErrorIs(t, err, ErrTeamMemberNotFound)
+
+	// a seed member without an id must not match a missing name
+	_, err = FindTeamMember(t.Context(), NewInMemoryRosterStorer(TeamMember{Name: "Ana"}), "")
+	assert.ErrorIs(t, err, ErrTeamMemberNotFound)
+}
+
+func TestNewTeamMember(t *testing.T) {
+	member, err := NewTeamMember(TeamMemberConfig{Name: " Ana ", DiscordID: "80351110224678912"}, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "Ana", member.Name)
+	assert.NotEmpty(t, member.Id)
+
+	member, err = NewTeamMember(TeamMemberConfig{Id: "ana", Name: "Ana"}, time.Now())
+	require.NoError(t, err)
+	assert.Equal(t, "ana", member.Id)
+
+	_, err = NewTeamMember(TeamMemberConfig{DiscordID: "80351110224678912"}, time.Now())
+	assert.Error(t, err)
+
+	_, err = NewTeamMember(TeamMemberConfig{Name: "Ana", DiscordID: "ana#1234"}, time.Now())
+	assert.ErrorIs(t, err, ErrInvalidPlatformID)
+}
+
+func TestTeamMemberMentions(t *testing.T) {
+	member := TeamMember{DiscordID: "123", GoogleChatID: "users/456", SlackID: "U789"}
+
+	assert.Equal(t, "<@123>", member.DiscordMention())
+	assert.Equal(t, "<users/456>", member.GoogleChatMention())
+	assert.Equal(t, "<@U789>", member.SlackMention())
+
+	member.GoogleChatID = "456"
+	assert.Equal(t, "<users/456>", member.GoogleChatMention())
+
+	assert.Empty(t, TeamMember{}.DiscordMention())
+	assert.Empty(t, TeamMember{}.GoogleChatMention())
+	assert.Empty(t, TeamMember{}.SlackMention())
+}
+
+func TestMentionMessageSender(t *testing.T) {
+	ana := TeamMember{Id: "1", Name: "Ana", DiscordID: "123"}
+	storer := NewInMemoryRosterStorer(ana)
+
+	mentioning := func(members ...TeamMember) any {
+		return mock.MatchedBy(func(ctx context.Context) bool {
+			return assert.ObjectsAreEqual(members, Mentions(ctx))
+		})
+	}
+
+	t.Run("broken message of a member", func(t *testing.T) {
+		mockSender := NewMockMessageSender(t)
+		mockSender.On("SendBrokenMessage", mentioning(ana), BrokenMessage{Name: "ana"}).Return(nil)
+
+		err := NewMentionMessageSender(mockSender, storer).SendBrokenMessage(t.Context(), BrokenMessage{Name: "ana"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("broken message of someone else", func(t *testing.T) {
+		mockSender := NewMockMessageSender(t)
+		mockSender.On("SendBrokenMessage", mentioning(), BrokenMessage{Name: "Carla"}).Return(nil)
+
+		err := NewMentionMessageSender(mockSender, storer).SendBrokenMessage(t.Context(), BrokenMessage{Name: "Carla"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("targeted message", func(t *testing.T) {
+		mockSender := NewMockMessageSender(t)
+		mockSender.On("SendMessage", mentioning(ana), Message{Id: "1"}).Return(nil)
+
+		ctx := WithMessageTarget(t.Context(), "1")
+
+		err := NewMentionMessageSender(mockSender, storer).SendMessage(ctx, Message{Id: "1"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("alert mentions who is on call", func(t *testing.T) {
+		mockSender := NewMockMessageSender(t)
+		mockSender.On("SendAlert", mentioning(ana), AlertNotification{OnCall: "Ana"}).Return(nil)
+
+		err := NewMentionMessageSender(mockSender, storer).SendAlert(t.Context(), AlertNotification{OnCall: "Ana"})
+		assert.NoError(t, err)
+	})
+
+	t.Run("sends without mentions when the roster fails", func(t *testing.T) {
+		failing := NewMockRosterStorer(t)
+		failing.On("GetAllTeamMembers", mock.Anything).Return(nil, errors.New("boom"))
+
+		mockSender := NewMockMessageSender(t)
+		mockSender.On("SendBrokenMessage", mentioning(), BrokenMessage{Name: "Ana"}).Return(nil)
+
+		err := NewMentionMessageSender(mockSender, failing).SendBrokenMessage(t.Context(), BrokenMessage{Name: "Ana"})
+		assert.NoError(t, err)
+	})
+}
+
+func TestInMemoryRosterStorerUpdateTeamMember(t *testing.T) {
+	storer := NewInMemoryRosterStorer(TeamMember{Id: "1", Name: "Ana", Aliases: []string{"ana-gh"}})
+
+	_, err := storer.UpdateTeamMember(t.Context(), "1", func(member *TeamMember) error {
+		member.Aliases = append(member.Aliases, "ana@example.com")
+		member.Aliases[0] = "changed"
+		return assert.AnError
+	})
+	assert.ErrorIs(t, err, assert.AnError)
+
+	member, err := storer.GetTeamMemberByID(t.Context(), "1")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"ana-gh"}, member.Aliases)
+
+	member, err = storer.UpdateTeamMember(t.Context(), "1", func(member *TeamMember) error {
+		member.Away = true
+		return nil
+	})
+	require.NoError(t, err)
+	assert.True(t, member.Away)
+
+	_, err = storer.UpdateTeamMember(t.Context(), "2", func(member *TeamMember) error { return nil })
+	assert.ErrorIs(t, err, ErrTeamMemberNotFound)
+}
+
+func TestCreateTeamMember(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		expectedCode int
+	}{
+		{name: "valid", body: `{"name":"Bruno","aliases":["bruno-gh"],"discord_id":"123"}`, expectedCode: http.StatusCreated},
+		{name: "missing name", body: `{"discord_id":"123"}`, expectedCode: http.StatusBadRequest},
+		{name: "name taken", body: `{"name":"ana"}`, expectedCode: http.StatusConflict},
+		{name: "alias taken", body: `{"name":"Bruno","aliases":["ana-gh"]}`, expectedCode: http.StatusConflict},
+		{name: "invalid discord id", body: `{"name":"Bruno","discord_id":"bruno#1234"}`, expectedCode: http.StatusBadRequest},
+		{name: "invalid body", body: `{`, expectedCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			storer := NewInMemoryRosterStorer(TeamMember{Id: "1", Name: "Ana", Aliases: []string{"ana-gh"}})
+			server := &Server{rosterStorer: storer, echoServer: e}
+
+			req := httptest.NewRequest(http.MethodPost, "/roster", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+
+			err := server.CreateTeamMember(e.NewContext(req, rec))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rec.Code)
+
+			members, err := storer.GetAllTeamMembers(t.Context())
+			require.NoError(t, err)
+			if tt.expectedCode == http.StatusCreated {
+				assert.Len(t, members, 2)
+			} else {
+				assert.Len(t, members, 1)
+			}
+		})
+	}
+}
+
+func TestDeleteTeamMember(t *testing.T) {
+	e := echo.New()
+	server := &Server{rosterStorer: NewInMemoryRosterStorer(TeamMember{Id: "1"}), echoServer: e}
+
+	for _, expectedCode := range []int{http.StatusNoContent, http.StatusNotFound} {
+		req := httptest.NewRequest(http.MethodDelete, "/roster/1", nil)
+		rec := httptest.NewRecorder()
+		c := e.NewContext(req, rec)
+		c.SetParamNames("id")
+		c.SetParamValues("1")
+
+		err := server.DeleteTeamMember(c)
+		require.NoError(t, err)
+		assert.Equal(t, expectedCode, rec.Code)
+	}
+}
+
+func TestSendMessageByIdWithTarget(t *testing.T) {
+	e := echo.New()
+
+	mockStore := NewMockMessageStorer(t)
+	mockStore.On("GetMessageByID", mock.Anything, "1").Return(&Message{Id: "1", Message: "{{name}}, bora?"}, nil)
+
+	mockSender := NewMockMessageSender(t)
+	mockSender.On("SendMessage", mock.Anything, mock.Anything).Return(nil)
+
+	roster := NewInMemoryRosterStorer(TeamMember{Id: "ana", Name: "Ana", DiscordID: "123"})
+
+	server := &Server{
+		messageStorer: mockStore,
+		messageSender: NewMentionMessageSender(NewPlaceholderMessageSender(mockSender, nil), roster),
+		rosterStorer:  roster,
+		sendMessages:  true,
+		echoServer:    e,
+	}
+
+	req := httptest.NewRequest(http.MethodPost, "/messages/1", strings.NewReader(`{"target":"ana"}`))
+	req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+	rec := httptest.NewRecorder()
+	c := e.NewContext(req, rec)
+	c.SetParamNames("id")
+	c.SetParamValues("1")
+
+	err := server.SendMessageById(c)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	ctx := mockSender.Calls[0].Arguments.Get(0).(context.Context)
+	assert.Equal(t, "Ana, bora?", mockSender.Calls[0].Arguments.Get(1).(Message).Message)
+	require.Len(t, Mentions(ctx), 1)
+	assert.Equal(t, "123", Mentions(ctx)[0].DiscordID)
+}
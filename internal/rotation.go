@@ -0,0 +1,229 @@
+package internal
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"maps"
+	"os"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+)
+
+var (
+	ErrNoTargetAvailable = errors.New("no team member available for the rotation")
+)
+
+// RotationStorer keeps when every member was last the target of the day, the
+// rotation is only fair if it survives restarts
+type RotationStorer interface {
+	GetLastTargeted(ctx context.Context) (map[string]time.Time, error)
+	SetLastTargeted(ctx context.Context, memberID string, at time.Time) error
+}
+
+type InMemoryRotationStorer struct {
+	mu           sync.RWMutex
+	lastTargeted map[string]time.Time
+}
+
+var (
+	_ RotationStorer = (*InMemoryRotationStorer)(nil)
+)
+
+func NewInMemoryRotationStorer() *InMemoryRotationStorer {
+	return &InMemoryRotationStorer{
+		lastTargeted: make(map[string]time.Time),
+	}
+}
+
+func (s *InMemoryRotationStorer) GetLastTargeted(ctx context.Context) (map[string]time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return maps.Clone(s.lastTargeted), nil
+}
+
+func (s *InMemoryRotationStorer) SetLastTargeted(ctx context.Context, memberID string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.lastTargeted[memberID] = at
+
+	return nil
+}
+
+// FileRotationStorer keeps the rotation in a JSON file, rewritten on every
+// change
+type FileRotationStorer struct {
+	mu           sync.RWMutex
+	path         string
+	lastTargeted map[string]time.Time
+}
+
+var (
+	_ RotationStorer = (*FileRotationStorer)(nil)
+)
+
+// NewFileRotationStorer loads the rotation from path, a missing file is an
+// empty rotation
+func NewFileRotationStorer(path string) (*FileRotationStorer, error) {
+	lastTargeted := make(map[string]time.Time)
+
+	data, err := os.ReadFile(path)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+
+	if len(data) > 0 {
+		err = json.Unmarshal(data, &lastTargeted)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &FileRotationStorer{
+		path:         path,
+		lastTargeted: lastTargeted,
+	}, nil
+}
+
+func (s *FileRotationStorer) GetLastTargeted(ctx context.Context) (map[string]time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return maps.Clone(s.lastTargeted), nil
+}
+
+func (s *FileRotationStorer) SetLastTargeted(ctx context.Context, memberID string, at time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	lastTargeted := maps.Clone(s.lastTargeted)
+	lastTargeted[memberID] = at
+
+	data, err := json.MarshalIndent(lastTargeted, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = WriteFileAtomic(s.path, data)
+	if err != nil {
+		return err
+	}
+
+	s.lastTargeted = lastTargeted
+
+	return nil
+}
+
+// Rotation picks the target of the day with a round-robin over the roster,
+// whoever went the longest without being the target goes next
+type Rotation struct {
+	roster RosterStorer
+	storer RotationStorer
+}
+
+func NewRotation(roster RosterStorer, storer RotationStorer) *Rotation {
+	return &Rotation{
+		roster: roster,
+		storer: storer,
+	}
+}
+
+// Next returns who is up on the day, skipping the members away then. Ties go
+// to the roster order, the members never targeted come first
+func (r *Rotation) Next(ctx context.Context, day time.Time) (*TeamMember, error) {
+	members, err := r.roster.GetAllTeamMembers(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	lastTargeted, err := r.storer.GetLastTargeted(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	members = slices.DeleteFunc(members, func(member TeamMember) bool {
+		return member.IsAway(day)
+	})
+	if len(members) == 0 {
+		return nil, ErrNoTargetAvailable
+	}
+
+	slices.SortStableFunc(members, func(a, b TeamMember) int {
+		return lastTargeted[a.Id].Compare(lastTargeted[b.Id])
+	})
+
+	return &members[0], nil
+}
+
+// Advance records the member as the target at the time, moving them to the
+// end of the rotation
+func (r *Rotation) Advance(ctx context.Context, member TeamMember, at time.Time) error {
+	return r.storer.SetLastTargeted(ctx, member.Id, at)
+}
+
+// Schedule tells when the rotation picks its next target
+type Schedule interface {
+	NextRun() (time.Time, error)
+}
+
+// SetRotation makes GET /roster/next answer with the rotation, for the next
+// run of the schedule
+func (s *Server) SetRotation(rotation *Rotation, schedule Schedule) {
+	s.rotation = rotation
+	s.schedule = schedule
+}
+
+type nextTargetResponse struct {
+	Date   string      `json:"date"`
+	Member *TeamMember `json:"member"`
+}
+
+// GetNextTarget shows who is up on the next run of the schedule, or on the
+// date query param
+func (s *Server) GetNextTarget(c echo.Context) error {
+	if s.rotation == nil {
+		return c.JSON(404, map[string]string{"error": "rotation is not configured"})
+	}
+
+	ctx := c.Request().Context()
+
+	var (
+		day time.Time
+		err error
+	)
+	if date := c.QueryParam("date"); date != "" {
+		day, err = time.ParseInLocation(time.DateOnly, date, time.Local)
+		if err != nil {
+			return c.JSON(400, map[string]string{"error": "date must be formatted as YYYY-MM-DD"})
+		}
+	} else {
+		day, err = s.schedule.NextRun()
+		if errors.Is(err, ErrNotRunning) {
+			return c.JSON(404, map[string]string{"error": "the message job is not scheduled"})
+		}
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to get the next run of the message job", slog.Any("error", err))
+			return c.JSON(500, map[string]string{"error": err.Error()})
+		}
+	}
+
+	member, err := s.rotation.Next(ctx, day)
+	if errors.Is(err, ErrNoTargetAvailable) {
+		return c.JSON(404, map[string]string{"error": err.Error()})
+	}
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to pick the next target", slog.Any("error", err))
+		return c.JSON(500, map[string]string{"error": err.Error()})
+	}
+
+	return c.JSON(200, nextTargetResponse{
+		Date:   day.Format(time.DateOnly),
+		Member: member,
+	})
+}
@@ -0,0 +1,211 @@
+package internal
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRotationRoundRobin(t *testing.T) {
+	roster := NewInMemoryRosterStorer(
+		TeamMember{Id: "ana", Name: "Ana"},
+		TeamMember{Id: "bruno", Name: "Bruno", Away: true},
+		TeamMember{Id: "carla", Name: "Carla"},
+	)
+	rotation := NewRotation(roster, NewInMemoryRotationStorer())
+
+	day := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+
+	var picked []string
+	for i := range 4 {
+		member, err := rotation.Next(t.Context(), day)
+		require.NoError(t, err)
+
+		picked = append(picked, member.Id)
+		require.NoError(t, rotation.Advance(t.Context(), *member, day.Add(time.Duration(i)*time.Hour)))
+	}
+
+	assert.Equal(t, []string{"ana", "carla", "ana", "carla"}, picked)
+
+	// back from vacation, the longest without a turn goes first
+	_, err := roster.UpdateTeamMember(t.Context(), "bruno", func(member *TeamMember) error {
+		member.Away = false
+		return nil
+	})
+	require.NoError(t, err)
+
+	member, err := rotation.Next(t.Context(), day)
+	require.NoError(t, err)
+	assert.Equal(t, "bruno", member.Id)
+}
+
+func TestRotationWithEverybodyAway(t *testing.T) {
+	rotation := NewRotation(NewInMemoryRosterStorer(TeamMember{Id: "ana", Away: true}), NewInMemoryRotationStorer())
+
+	_, err := rotation.Next(t.Context(), time.Now())
+	assert.ErrorIs(t, err, ErrNoTargetAvailable)
+}
+
+func TestFileRotationStorer(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rotation.json")
+	at := time.Date(2026, 10, 19, 8, 0, 0, 0, time.UTC)
+
+	storer, err := NewFileRotationStorer(path)
+	require.NoError(t, err)
+	require.NoError(t, storer.SetLastTargeted(t.Context(), "ana", at))
+
+	reloaded, err := NewFileRotationStorer(path)
+	require.NoError(t, err)
+
+	lastTargeted, err := reloaded.GetLastTargeted(t.Context())
+	require.NoError(t, err)
+	assert.True(t, at.Equal(lastTargeted["ana"]))
+}
+
+// fixedSchedule runs next at the time, or fails with the error
+type fixedSchedule struct {
+	next time.Time
+	err  error
+}
+
+func (s fixedSchedule) NextRun() (time.Time, error) {
+	return s.next, s.err
+}
+
+func TestGetNextTarget(t *testing.T) {
+	roster := NewInMemoryRosterStorer(TeamMember{Id: "ana", Name: "Ana"})
+
+	// a friday run, the next target is not tomorrow's
+	schedule := fixedSchedule{next: time.Date(2026, 10, 23, 9, 0, 0, 0, time.Local)}
+
+	tests := []struct {
+		name         string
+		query        string
+		rotation     *Rotation
+		schedule     Schedule
+		expectedCode int
+		expectedDate string
+	}{
+		{name: "next run", rotation: NewRotation(roster, NewInMemoryRotationStorer()), schedule: schedule, expectedCode: http.StatusOK, expectedDate: "2026-10-23"},
+		{name: "on a date", query: "?date=2026-10-20", rotation: NewRotation(roster, NewInMemoryRotationStorer()), schedule: schedule, expectedCode: http.StatusOK, expectedDate: "2026-10-20"},
+		{name: "invalid date", query: "?date=20/10/2026", rotation: NewRotation(roster, NewInMemoryRotationStorer()), schedule: schedule, expectedCode: http.StatusBadRequest},
+		{name: "not scheduled", rotation: NewRotation(roster, NewInMemoryRotationStorer()), schedule: fixedSchedule{err: ErrNotRunning}, expectedCode: http.StatusNotFound},
+		{name: "empty roster", rotation: NewRotation(NewInMemoryRosterStorer(), NewInMemoryRotationStorer()), schedule: schedule, expectedCode: http.StatusNotFound},
+		{name: "no rotation", expectedCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			server := &Server{rosterStorer: roster, rotation: tt.rotation, schedule: tt.schedule, echoServer: e}
+
+			rec := httptest.NewRecorder()
+			err := server.GetNextTarget(e.NewContext(httptest.NewRequest(http.MethodGet, "/roster/next"+tt.query, nil), rec))
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rec.Code)
+
+			if tt.expectedCode == http.StatusOK {
+				var resp nextTargetResponse
+				require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &resp))
+				assert.Equal(t, "ana", resp.Member.Id)
+				assert.Equal(t, tt.expectedDate, resp.Date)
+			}
+		})
+	}
+}
+
+func TestUpdateTeamMember(t *testing.T) {
+	tests := []struct {
+		name         string
+		id           string
+		body         string
+		expectedCode int
+	}{
+		{name: "marks away", id: "ana", body: `{"away":true}`, expectedCode: http.StatusOK},
+		{name: "nothing to update", id: "ana", body: `{}`, expectedCode: http.StatusBadRequest},
+		{name: "unknown member", id: "bruno", body: `{"away":true}`, expectedCode: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			storer := NewInMemoryRosterStorer(TeamMember{Id: "ana", Name: "Ana"})
+			server := &Server{rosterStorer: storer, echoServer: e}
+
+			req := httptest.NewRequest(http.MethodPatch, "/roster/"+tt.id, strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues(tt.id)
+
+			err := server.UpdateTeamMember(c)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rec.Code)
+
+			member, err := storer.GetTeamMemberByID(t.Context(), "ana")
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode == http.StatusOK, member.Away)
+		})
+	}
+}
+
+func TestUpdateTeamMemberFields(t *testing.T) {
+	ana := TeamMember{Id: "ana", Name: "Ana", Aliases: []string{"ana-gh"}, DiscordID: "80351110224678912", SlackID: "U0123ABCD"}
+
+	tests := []struct {
+		name         string
+		body         string
+		expectedCode int
+		expected     TeamMember
+	}{
+		{
+			name:         "renames keeping the rest",
+			body:         `{"name":" Ana Maria "}`,
+			expectedCode: http.StatusOK,
+			expected:     TeamMember{Id: "ana", Name: "Ana Maria", Aliases: []string{"ana-gh"}, DiscordID: "80351110224678912", SlackID: "U0123ABCD"},
+		},
+		{
+			name:         "changes the platform users",
+			body:         `{"aliases":["ana-gl"],"discord_id":"175928847299117063","google_chat_id":"users/123","slack_id":""}`,
+			expectedCode: http.StatusOK,
+			expected:     TeamMember{Id: "ana", Name: "Ana", Aliases: []string{"ana-gl"}, DiscordID: "175928847299117063", GoogleChatID: "users/123"},
+		},
+		{name: "empty name", body: `{"name":" "}`, expectedCode: http.StatusBadRequest, expected: ana},
+		{name: "discord id not a snowflake", body: `{"discord_id":"ana#1234"}`, expectedCode: http.StatusBadRequest, expected: ana},
+		{name: "invalid google chat id", body: `{"google_chat_id":"ana@example.com"}`, expectedCode: http.StatusBadRequest, expected: ana},
+		{name: "invalid slack id", body: `{"slack_id":"@ana"}`, expectedCode: http.StatusBadRequest, expected: ana},
+		{name: "name of another member", body: `{"aliases":["ana-gh","Bruno"]}`, expectedCode: http.StatusConflict, expected: ana},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			e := echo.New()
+			storer := NewInMemoryRosterStorer(ana, TeamMember{Id: "bruno", Name: "Bruno"})
+			server := &Server{rosterStorer: storer, echoServer: e}
+
+			req := httptest.NewRequest(http.MethodPatch, "/roster/ana", strings.NewReader(tt.body))
+			req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+			rec := httptest.NewRecorder()
+			c := e.NewContext(req, rec)
+			c.SetParamNames("id")
+			c.SetParamValues("ana")
+
+			err := server.UpdateTeamMember(c)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedCode, rec.Code)
+
+			member, err := storer.GetTeamMemberByID(t.Context(), "ana")
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, *member)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package slack
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/taldoflemis/wilson-bot/internal"
+)
+
+type text struct {
+	Type string `json:"type"`
+	Text string `json:"text"`
+}
+
+type block struct {
+	Type     string `json:"type"`
+	Text     *text  `json:"text,omitempty"`
+	Fields   []text `json:"fields,omitempty"`
+	ImageURL string `json:"image_url,omitempty"`
+	AltText  string `json:"alt_text,omitempty"`
+}
+
+// message is the Block Kit body used both for slash command responses and
+// chat.postMessage calls
+type message struct {
+	ResponseType string  `json:"response_type,omitempty"`
+	Channel      string  `json:"channel,omitempty"`
+	Text         string  `json:"text"`
+	Blocks       []block `json:"blocks,omitempty"`
+}
+
+// Block Kit limits, the replies are single messages so texts are truncated
+const (
+	sectionTextLimit = 3000
+	fieldTextLimit   = 2000
+)
+
+func plainText(s string) *text {
+	return &text{Type: "plain_text", Text: s}
+}
+
+func markdown(s string) text {
+	return text{Type: "mrkdwn", Text: s}
+}
+
+func messageBlocks(m internal.Message) []block {
+	body := markdown("*_" + internal.TruncateText(m.Message, sectionTextLimit-4) + "_*")
+
+	return []block{
+		{Type: "header", Text: plainText("Já agradeceu por trabalhar com o Wilson hoje?")},
+		{Type: "section", Text: &body},
+		{Type: "image", ImageURL: internal.ImageOrDefault(m.ImageURL, internal.DefaultMessageImageURL), AltText: "Pepe"},
+	}
+}
+
+func brokenMessageBlocks(m internal.BrokenMessage) []block {
+	return []block{
+		{Type: "header", Text: plainText("Broken Time")},
+		{Type: "section", Fields: []text{
+			markdown("*Pessoa:*\n" + internal.TruncateText(m.Name, fieldTextLimit-10)),
+			markdown("*Motivo:*\n" + internal.TruncateText(m.Motive, fieldTextLimit-10)),
+			markdown("*Tempo sem quebra:*\n" + m.TimeSinceBroken),
+			markdown("*Dia da quebra:*\n" + m.DayOfBreakage),
+		}},
+		{Type: "image", ImageURL: internal.ImageOrDefault(m.ImageURL, internal.DefaultBrokenImageURL), AltText: "Broken Time"},
+	}
+}
+
+func leaderboardBlocks(leaderboard []internal.LeaderboardEntry) []block {
+	var sb strings.Builder
+	for i, entry := range leaderboard {
+		fmt.Fprintf(&sb, "%d. %s - %d quebra(s)\n", i+1, entry.Name, entry.Breakages)
+	}
+
+	body := markdown(sb.String())
+
+	return []block{
+		{Type: "header", Text: plainText("Ranking de quebras")},
+		{Type: "section", Text: &body},
+	}
+}
@@ -0,0 +1,384 @@
+package slack
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/labstack/echo/v4"
+
+	"github.com/taldoflemis/wilson-bot/internal"
+)
+
+const (
+	signatureHeader          = "X-Slack-Signature"
+	signatureTimestampHeader = "X-Slack-Request-Timestamp"
+	signatureVersion         = "v0"
+	retryNumHeader           = "X-Slack-Retry-Num"
+
+	maxRequestAge = 5 * time.Minute
+
+	eventTypeURLVerification = "url_verification"
+	eventTypeEventCallback   = "event_callback"
+	eventTypeAppMention      = "app_mention"
+)
+
+var (
+	ErrInvalidSignature     = errors.New("invalid request signature")
+	ErrStaleRequest         = errors.New("request timestamp too old")
+	ErrMissingSigningSecret = errors.New("slack signing secret is required")
+
+	mentionPattern = regexp.MustCompile(`<@[^>]+>`)
+)
+
+type eventEnvelope struct {
+	Type      string `json:"type"`
+	Challenge string `json:"challenge"`
+	EventID   string `json:"event_id"`
+	Event     struct {
+		Type    string `json:"type"`
+		Text    string `json:"text"`
+		Channel string `json:"channel"`
+		User    string `json:"user"`
+	} `json:"event"`
+}
+
+type postMessageResponse struct {
+	Ok    bool   `json:"ok"`
+	Error string `json:"error"`
+}
+
+// Receiver handles the Slack slash commands and Events API callbacks
+type Receiver struct {
+	signingSecret   string
+	botToken        string
+	apiURL          string
+	httpClient      *http.Client
+	now             func() time.Time
+	messageStorer   internal.MessageStorer
+	messageSender   internal.MessageSender
+	breakageTracker internal.BreakageTracker
+	placeholders    map[string]string
+	background      *internal.Background
+	events          *eventCache
+}
+
+var (
+	_ internal.RouteRegisterer = (*Receiver)(nil)
+)
+
+func NewReceiver(
+	cfg internal.SlackConfig,
+	messageStorer internal.MessageStorer,
+	messageSender internal.MessageSender,
+	breakageTracker internal.BreakageTracker,
+	placeholders map[string]string,
+	background *internal.Background,
+) (*Receiver, error) {
+	// without the secret anyone could run the commands
+	if cfg.SigningSecret == "" {
+		return nil, ErrMissingSigningSecret
+	}
+
+	return &Receiver{
+		signingSecret:   cfg.SigningSecret,
+		botToken:        cfg.BotToken,
+		apiURL:          strings.TrimSuffix(cfg.APIURL, "/"),
+		httpClient:      internal.NewTracedHTTPClient(10 * time.Second),
+		now:             time.Now,
+		messageStorer:   messageStorer,
+		messageSender:   messageSender,
+		breakageTracker: breakageTracker,
+		placeholders:    placeholders,
+		background:      background,
+		events:          newEventCache(time.Hour),
+	}, nil
+}
+
+func (r *Receiver) RegisterRoutes(g *echo.Group) {
+	g.POST("/slack/commands", r.HandleCommand, internal.TriggerMiddleware(internal.TriggerCommand))
+	g.POST("/slack/events", r.HandleEvent, internal.TriggerMiddleware(internal.TriggerCommand))
+}
+
+// VerifySignature checks the HMAC-SHA256 signature Slack computes with the app
+// signing secret over the version, the timestamp and the raw body
+func VerifySignature(secret string, header http.Header, body []byte, now time.Time) error {
+	timestamp := header.Get(signatureTimestampHeader)
+
+	seconds, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return ErrInvalidSignature
+	}
+
+	age := now.Sub(time.Unix(seconds, 0))
+	if age > maxRequestAge || age < -maxRequestAge {
+		return ErrStaleRequest
+	}
+
+	mac := hmac.New(sha256.New, []byte(secret))
+	fmt.Fprintf(mac, "%s:%s:", signatureVersion, timestamp)
+	mac.Write(body)
+
+	expected := signatureVersion + "=" + hex.EncodeToString(mac.Sum(nil))
+	if !hmac.Equal([]byte(expected), []byte(header.Get(signatureHeader))) {
+		return ErrInvalidSignature
+	}
+
+	return nil
+}
+
+func (r *Receiver) readVerifiedBody(c echo.Context) ([]byte, error) {
+	body, err := io.ReadAll(c.Request().Body)
+	if err != nil {
+		return nil, err
+	}
+
+	err = VerifySignature(r.signingSecret, c.Request().Header, body, r.now())
+	if err != nil {
+		return nil, err
+	}
+
+	return body, nil
+}
+
+func (r *Receiver) HandleCommand(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	body, err := r.readVerifiedBody(c)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": err.Error()})
+	}
+
+	form, err := url.ParseQuery(string(body))
+	if err != nil {
+		return c.JSON(400, map[string]string{"error": "invalid request"})
+	}
+
+	command, args := form.Get("command"), strings.TrimSpace(form.Get("text"))
+
+	var reply *message
+
+	switch command {
+	case "/wilson":
+		reply, err = r.wilsonReply(ctx, args, form.Get("user_id"))
+	case "/broken":
+		reply, err = r.brokenReply(ctx, args)
+	case "/wilson-stats":
+		reply, err = r.statsReply(ctx)
+	default:
+		reply = &message{Text: "Comando desconhecido"}
+	}
+
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to handle slack command", slog.String("command", command), slog.Any("error", err))
+		reply = &message{Text: "Deu ruim, tente novamente mais tarde"}
+	}
+
+	return c.JSON(200, reply)
+}
+
+func (r *Receiver) HandleEvent(c echo.Context) error {
+	ctx := c.Request().Context()
+
+	body, err := r.readVerifiedBody(c)
+	if err != nil {
+		return c.JSON(401, map[string]string{"error": err.Error()})
+	}
+
+	var envelope eventEnvelope
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return c.JSON(400, map[string]string{"error": "invalid request"})
+	}
+
+	switch envelope.Type {
+	case eventTypeURLVerification:
+		return c.JSON(200, map[string]string{"challenge": envelope.Challenge})
+	case eventTypeEventCallback:
+	default:
+		return c.NoContent(200)
+	}
+
+	if envelope.Event.Type != eventTypeAppMention {
+		return c.NoContent(200)
+	}
+
+	// Slack retries the events it thinks were lost, the retries of an event
+	// already taken are only acknowledged
+	if !r.events.claim(envelope.EventID, time.Now()) {
+		slog.InfoContext(ctx, "ignoring slack event retry", slog.String("event_id", envelope.EventID), slog.String("retry_num", c.Request().Header.Get(retryNumHeader)))
+		return c.NoContent(200)
+	}
+
+	tag := strings.TrimSpace(mentionPattern.ReplaceAllString(envelope.Event.Text, ""))
+
+	// Slack expects the answer in three seconds, the reply is posted after it
+	r.background.Go(ctx, "slack app mention", func(ctx context.Context) error {
+		reply, err := r.wilsonReply(ctx, tag, envelope.Event.User)
+		if err != nil {
+			return err
+		}
+
+		reply.ResponseType = ""
+		reply.Channel = envelope.Event.Channel
+
+		return r.postMessage(ctx, reply)
+	})
+
+	return c.NoContent(200)
+}
+
+// eventCache remembers the events already taken, until Slack stops retrying
+// them
+type eventCache struct {
+	mu   sync.Mutex
+	ttl  time.Duration
+	seen map[string]time.Time
+}
+
+func newEventCache(ttl time.Duration) *eventCache {
+	return &eventCache{
+		ttl:  ttl,
+		seen: make(map[string]time.Time),
+	}
+}
+
+// claim reports if the event wasn't seen yet and marks it as seen
+func (e *eventCache) claim(id string, now time.Time) bool {
+	if id == "" {
+		return true
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	for event, seenAt := range e.seen {
+		if now.Sub(seenAt) > e.ttl {
+			delete(e.seen, event)
+		}
+	}
+
+	if _, ok := e.seen[id]; ok {
+		return false
+	}
+
+	e.seen[id] = now
+
+	return true
+}
+
+// wilsonReply picks a random message, using the arguments as a tag filter and
+// falling back to any message when nothing matches. The message mentions the
+// user who asked for it
+func (r *Receiver) wilsonReply(ctx context.Context, tag string, userID string) (*message, error) {
+	m, err := internal.GetRandomMessage(ctx, r.messageStorer, tag)
+	if errors.Is(err, internal.ErrMessageNotFound) && tag != "" {
+		m, err = internal.GetRandomMessage(ctx, r.messageStorer, "")
+	}
+	if errors.Is(err, internal.ErrMessageNotFound) {
+		return &message{Text: "Nenhuma mensagem encontrada"}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	vars := map[string]string{}
+	if userID != "" {
+		vars["name"] = "<@" + userID + ">"
+	}
+
+	rendered := internal.RenderMessagePlaceholders(ctx, *m, r.placeholders, vars)
+
+	return &message{
+		ResponseType: "in_channel",
+		Text:         rendered.Message,
+		Blocks:       messageBlocks(rendered),
+	}, nil
+}
+
+// brokenReply expects the name of who broke it followed by the motive
+func (r *Receiver) brokenReply(ctx context.Context, args string) (*message, error) {
+	name, motive, _ := strings.Cut(args, " ")
+	motive = strings.TrimSpace(motive)
+	if name == "" || motive == "" {
+		return &message{Text: "Uso: /broken <nome> <motivo>"}, nil
+	}
+
+	brokenMessage, err := internal.NewBrokenMessage(ctx, r.breakageTracker, name, motive, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	// the card goes out after the answer, Slack waits three seconds at most
+	r.background.Go(ctx, "slack broken command", func(ctx context.Context) error {
+		return r.messageSender.SendBrokenMessage(ctx, brokenMessage)
+	})
+
+	return &message{
+		ResponseType: "in_channel",
+		Text:         fmt.Sprintf("Quebra registrada para %s: %s", brokenMessage.Name, brokenMessage.Motive),
+		Blocks:       brokenMessageBlocks(brokenMessage),
+	}, nil
+}
+
+func (r *Receiver) statsReply(ctx context.Context) (*message, error) {
+	leaderboard, err := r.breakageTracker.GetLeaderboard(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(leaderboard) == 0 {
+		return &message{Text: "Ninguém quebrou nada ainda"}, nil
+	}
+
+	return &message{
+		ResponseType: "in_channel",
+		Text:         "Ranking de quebras",
+		Blocks:       leaderboardBlocks(leaderboard[:min(len(leaderboard), 10)]),
+	}, nil
+}
+
+func (r *Receiver) postMessage(ctx context.Context, m *message) error {
+	payload, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.apiURL+"/chat.postMessage", bytes.NewReader(payload))
+	if err != nil {
+		return err
+	}
+
+	req.Header.Set("Content-Type", "application/json; charset=utf-8")
+	req.Header.Set("Authorization", "Bearer "+r.botToken)
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	var result postMessageResponse
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
+		return err
+	}
+
+	if !result.Ok {
+		return fmt.Errorf("slack api error: %s", result.Error)
+	}
+
+	return nil
+}
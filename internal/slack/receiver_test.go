@@ -0,0 +1,210 @@
+package slack
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/taldoflemis/wilson-bot/internal"
+)
+
+const testSigningSecret = "8f742231b10e8888abcd99yyyzzz85a5"
+
+func newSignedRequest(target string, contentType string, body string, timestamp time.Time) *http.Request {
+	ts := strconv.FormatInt(timestamp.Unix(), 10)
+
+	mac := hmac.New(sha256.New, []byte(testSigningSecret))
+	mac.Write([]byte("v0:" + ts + ":" + body))
+
+	req := httptest.NewRequest(http.MethodPost, target, bytes.NewBufferString(body))
+	req.Header.Set(echo.HeaderContentType, contentType)
+	req.Header.Set(signatureTimestampHeader, ts)
+	req.Header.Set(signatureHeader, "v0="+hex.EncodeToString(mac.Sum(nil)))
+
+	return req
+}
+
+func newCommandRequest(command string, text string, timestamp time.Time) *http.Request {
+	form := url.Values{"command": {command}, "text": {text}, "user_id": {"U123"}}
+	return newSignedRequest("/slack/commands", echo.MIMEApplicationForm, form.Encode(), timestamp)
+}
+
+func TestVerifySignature(t *testing.T) {
+	now := time.Now()
+	req := newSignedRequest("/slack/events", echo.MIMEApplicationJSON, `{"type":"url_verification"}`, now)
+
+	assert.NoError(t, VerifySignature(testSigningSecret, req.Header, []byte(`{"type":"url_verification"}`), now))
+	assert.ErrorIs(t, VerifySignature("other-secret", req.Header, []byte(`{"type":"url_verification"}`), now), ErrInvalidSignature)
+	assert.ErrorIs(t, VerifySignature(testSigningSecret, req.Header, []byte(`{"type":"tampered"}`), now), ErrInvalidSignature)
+	assert.ErrorIs(t, VerifySignature(testSigningSecret, req.Header, []byte(`{"type":"url_verification"}`), now.Add(10*time.Minute)), ErrStaleRequest)
+}
+
+func TestHandleWilsonCommand(t *testing.T) {
+	e := echo.New()
+	mockStore := internal.NewMockMessageStorer(t)
+	mockStore.On("GetAllMessages", mock.Anything).Return([]internal.Message{
+		{Id: "1", Message: "Compila na minha máquina", Tags: []string{"tech"}},
+	}, nil)
+
+	receiver, err := NewReceiver(internal.SlackConfig{SigningSecret: testSigningSecret}, mockStore, nil, nil, nil, internal.NewBackground(time.Minute))
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(newCommandRequest("/wilson", "tech", time.Now()), rec)
+
+	err = receiver.HandleCommand(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var reply message
+	require.NoError(t, json.Unmarshal(rec.Body.Bytes(), &reply))
+	assert.Equal(t, "in_channel", reply.ResponseType)
+	assert.Equal(t, "Compila na minha máquina", reply.Text)
+	assert.NotEmpty(t, reply.Blocks)
+}
+
+func TestHandleBrokenCommand(t *testing.T) {
+	e := echo.New()
+	tracker := internal.NewInMemoryBreakageTracker()
+	mockSender := internal.NewMockMessageSender(t)
+	mockSender.On("SendBrokenMessage", mock.Anything, mock.MatchedBy(func(m internal.BrokenMessage) bool {
+		return m.Name == "Wilson" && m.Motive == "Subiu sem testar"
+	})).Return(nil)
+
+	receiver, err := NewReceiver(internal.SlackConfig{SigningSecret: testSigningSecret}, nil, mockSender, tracker, nil, internal.NewBackground(time.Minute))
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(newCommandRequest("/broken", "Wilson Subiu sem testar", time.Now()), rec)
+
+	err = receiver.HandleCommand(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "Quebra registrada para Wilson")
+
+	receiver.background.Wait()
+	mockSender.AssertExpectations(t)
+}
+
+func TestHandleCommandInvalidSignature(t *testing.T) {
+	e := echo.New()
+	receiver, err := NewReceiver(internal.SlackConfig{SigningSecret: "other-secret"}, nil, nil, nil, nil, internal.NewBackground(time.Minute))
+	require.NoError(t, err)
+
+	rec := httptest.NewRecorder()
+	c := e.NewContext(newCommandRequest("/wilson", "", time.Now()), rec)
+
+	err = receiver.HandleCommand(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+}
+
+func TestHandleEventURLVerification(t *testing.T) {
+	e := echo.New()
+	receiver, err := NewReceiver(internal.SlackConfig{SigningSecret: testSigningSecret}, nil, nil, nil, nil, internal.NewBackground(time.Minute))
+	require.NoError(t, err)
+
+	body := `{"type":"url_verification","challenge":"3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P"}`
+	rec := httptest.NewRecorder()
+	c := e.NewContext(newSignedRequest("/slack/events", echo.MIMEApplicationJSON, body, time.Now()), rec)
+
+	err = receiver.HandleEvent(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "3eZbrw1aBm2rZgRNFdxV2595E9CY3gmdALWMmHkvFXO7tYXAYM8P")
+}
+
+func TestHandleEventAppMention(t *testing.T) {
+	e := echo.New()
+	mockStore := internal.NewMockMessageStorer(t)
+	mockStore.On("GetAllMessages", mock.Anything).Return([]internal.Message{
+		{Id: "1", Message: "Não vale uma sibalena vencida", Tags: []string{"general"}},
+	}, nil)
+
+	var posted message
+	slackAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/chat.postMessage", r.URL.Path)
+		assert.Equal(t, "Bearer xoxb-test", r.Header.Get("Authorization"))
+
+		body, _ := io.ReadAll(r.Body)
+		_ = json.Unmarshal(body, &posted)
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer slackAPI.Close()
+
+	cfg := internal.SlackConfig{SigningSecret: testSigningSecret, BotToken: "xoxb-test", APIURL: slackAPI.URL}
+	receiver, err := NewReceiver(cfg, mockStore, nil, nil, nil, internal.NewBackground(time.Minute))
+	require.NoError(t, err)
+
+	body := `{"type":"event_callback","event":{"type":"app_mention","text":"<@U0LAN0Z89> general","channel":"C123"}}`
+	rec := httptest.NewRecorder()
+	c := e.NewContext(newSignedRequest("/slack/events", echo.MIMEApplicationJSON, body, time.Now()), rec)
+
+	err = receiver.HandleEvent(c)
+
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	// the reply is posted after the answer
+	receiver.background.Wait()
+	assert.Equal(t, "C123", posted.Channel)
+	assert.Equal(t, "Não vale uma sibalena vencida", posted.Text)
+}
+
+func TestHandleEventIgnoresRetries(t *testing.T) {
+	e := echo.New()
+	mockStore := internal.NewMockMessageStorer(t)
+	mockStore.On("GetAllMessages", mock.Anything).Return([]internal.Message{
+		{Id: "1", Message: "Não vale uma sibalena vencida", Tags: []string{"general"}},
+	}, nil).Once()
+
+	var posts atomic.Int32
+	slackAPI := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		posts.Add(1)
+		_, _ = w.Write([]byte(`{"ok":true}`))
+	}))
+	defer slackAPI.Close()
+
+	cfg := internal.SlackConfig{SigningSecret: testSigningSecret, BotToken: "xoxb-test", APIURL: slackAPI.URL}
+	receiver, err := NewReceiver(cfg, mockStore, nil, nil, nil, internal.NewBackground(time.Minute))
+	require.NoError(t, err)
+
+	body := `{"type":"event_callback","event_id":"Ev123","event":{"type":"app_mention","text":"<@U0LAN0Z89> general","channel":"C123"}}`
+	for retry := range 2 {
+		req := newSignedRequest("/slack/events", echo.MIMEApplicationJSON, body, time.Now())
+		if retry > 0 {
+			req.Header.Set(retryNumHeader, strconv.Itoa(retry))
+		}
+
+		rec := httptest.NewRecorder()
+		require.NoError(t, receiver.HandleEvent(e.NewContext(req, rec)))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	}
+
+	receiver.background.Wait()
+	assert.Equal(t, int32(1), posts.Load())
+}
+
+func TestNewReceiverRequiresSigningSecret(t *testing.T) {
+	_, err := NewReceiver(internal.SlackConfig{}, nil, nil, nil, nil, internal.NewBackground(time.Minute))
+	assert.ErrorIs(t, err, ErrMissingSigningSecret)
+}
@@ -0,0 +1,58 @@
+package slack
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+
+	"github.com/taldoflemis/wilson-bot/internal"
+)
+
+// Renderer renders the chat.postMessage body the bot would send for the
+// content, without the channel that comes from the triggering event
+type Renderer struct{}
+
+var (
+	_ internal.Renderer = (*Renderer)(nil)
+)
+
+func NewRenderer() *Renderer {
+	return &Renderer{}
+}
+
+func (r *Renderer) Render(ctx context.Context, content any) ([]byte, error) {
+	var m message
+
+	switch c := content.(type) {
+	case internal.Message:
+		m = message{Text: c.Message, Blocks: messageBlocks(c)}
+	case internal.BrokenMessage:
+		m = message{Text: c.Name + ": " + c.Motive, Blocks: brokenMessageBlocks(c)}
+	default:
+		return nil, internal.ErrUnsupportedContent
+	}
+
+	return json.Marshal(withMentions(m, internal.Mentions(ctx)))
+}
+
+// withMentions opens the message with the mentions, in the fallback text as
+// well since it is what the notifications show
+func withMentions(m message, members []internal.TeamMember) message {
+	var mentions []string
+	for _, member := range members {
+		if mention := member.SlackMention(); mention != "" {
+			mentions = append(mentions, mention)
+		}
+	}
+
+	if len(mentions) == 0 {
+		return m
+	}
+
+	body := markdown(strings.Join(mentions, " "))
+
+	m.Text = body.Text + " " + m.Text
+	m.Blocks = append([]block{{Type: "section", Text: &body}}, m.Blocks...)
+
+	return m
+}
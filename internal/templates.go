@@ -0,0 +1,237 @@
+package internal
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+var (
+	ErrInvalidPayload = errors.New("rendered payload is not valid JSON")
+)
+
+// TemplateFuncs are available to every card template, any value placed
+// inside a JSON string must go through jsonEscape
+var TemplateFuncs = template.FuncMap{
+	"jsonEscape": jsonEscape,
+}
+
+// jsonEscape escapes the value to be placed between the quotes of a JSON
+// string, leaving accents and HTML alone
+func jsonEscape(v any) (string, error) {
+	var buf bytes.Buffer
+
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+
+	err := encoder.Encode(fmt.Sprint(v))
+	if err != nil {
+		return "", err
+	}
+
+	escaped := strings.TrimSuffix(buf.String(), "\n")
+
+	return escaped[1 : len(escaped)-1], nil
+}
+
+// ExecuteJSONTemplate renders the card template, failing if the result
+// isn't valid JSON so a broken template never reaches the platform
+func ExecuteJSONTemplate(tmpl *template.Template, data any) ([]byte, error) {
+	var buf bytes.Buffer
+
+	err := tmpl.Execute(&buf, data)
+	if err != nil {
+		return nil, err
+	}
+
+	if !json.Valid(buf.Bytes()) {
+		return nil, fmt.Errorf("%w: template %s", ErrInvalidPayload, tmpl.Name())
+	}
+
+	return buf.Bytes(), nil
+}
+
+// TemplateSet holds the card templates of a platform. Each template is read
+// from dir when present there, falling back to the embedded default, and
+// only replaces the active ones if every template is valid
+type TemplateSet struct {
+	platform string
+	dir      string
+	fallback fs.FS
+	samples  map[string]any
+
+	mu        sync.RWMutex
+	templates map[string]*template.Template
+}
+
+// NewTemplateSet loads the templates named by the samples keys, each sample
+// is executed to validate its template
+func NewTemplateSet(platform string, dir string, fallback fs.FS, samples map[string]any) (*TemplateSet, error) {
+	s := &TemplateSet{
+		platform: platform,
+		dir:      dir,
+		fallback: fallback,
+		samples:  samples,
+	}
+
+	err := s.Reload()
+	if err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// Reload reads the templates again, keeping the previous ones if any of the
+// new ones is invalid
+func (s *TemplateSet) Reload() error {
+	templates := make(map[string]*template.Template, len(s.samples))
+
+	for name, sample := range s.samples {
+		tmpl, err := s.load(name, sample)
+		if err != nil {
+			return fmt.Errorf("%s template %s: %w", s.platform, name, err)
+		}
+
+		templates[name] = tmpl
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.templates = templates
+
+	return nil
+}
+
+func (s *TemplateSet) load(name string, sample any) (*template.Template, error) {
+	raw, err := s.read(name)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpl, err := template.New(name).Funcs(TemplateFuncs).Parse(string(raw))
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = ExecuteJSONTemplate(tmpl, sample)
+	if err != nil {
+		return nil, err
+	}
+
+	return tmpl, nil
+}
+
+func (s *TemplateSet) read(name string) ([]byte, error) {
+	if s.dir != "" {
+		raw, err := os.ReadFile(filepath.Join(s.dir, name))
+		if err == nil {
+			return raw, nil
+		}
+		if !errors.Is(err, fs.ErrNotExist) {
+			return nil, err
+		}
+	}
+
+	return fs.ReadFile(s.fallback, name)
+}
+
+// Execute renders the active template with the data
+func (s *TemplateSet) Execute(name string, data any) ([]byte, error) {
+	s.mu.RLock()
+	tmpl, ok := s.templates[name]
+	s.mu.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("%s template %s not loaded", s.platform, name)
+	}
+
+	return ExecuteJSONTemplate(tmpl, data)
+}
+
+// Watch reloads the templates whenever a file in the directory changes, until
+// the context is done. Without a directory there is nothing to watch
+func (s *TemplateSet) Watch(ctx context.Context) error {
+	if s.dir == "" {
+		return nil
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+
+	err = watcher.Add(s.dir)
+	if err != nil {
+		watcher.Close()
+		return err
+	}
+
+	go func() {
+		defer watcher.Close()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+
+				if _, tracked := s.samples[filepath.Base(event.Name)]; !tracked {
+					continue
+				}
+
+				s.reloadAndLog(ctx)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+
+				slog.ErrorContext(ctx, "template watcher failed", slog.String("platform", s.platform), slog.Any("error", err))
+			}
+		}
+	}()
+
+	return nil
+}
+
+func (s *TemplateSet) reloadAndLog(ctx context.Context) {
+	err := s.Reload()
+	if err != nil {
+		slog.ErrorContext(ctx, "invalid templates, keeping the previous ones", slog.String("platform", s.platform), slog.Any("error", err))
+		return
+	}
+
+	slog.InfoContext(ctx, "templates reloaded", slog.String("platform", s.platform), slog.String("dir", s.dir))
+}
+
+// ReloadTemplatesOnSignal reloads every set whenever the signal arrives, it
+// is meant for SIGHUP
+func ReloadTemplatesOnSignal(ctx context.Context, signals <-chan os.Signal, sets ...*TemplateSet) {
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-signals:
+				for _, s := range sets {
+					s.reloadAndLog(ctx)
+				}
+			}
+		}
+	}()
+}
@@ -0,0 +1,97 @@
+package internal
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"text/template"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestJSONEscape(t *testing.T) {
+	escaped, err := jsonEscape("Ele disse \"não\"\ne saiu <correndo> & rindo")
+
+	require.NoError(t, err)
+	assert.Equal(t, `Ele disse \"não\"\ne saiu <correndo> & rindo`, escaped)
+}
+
+func TestExecuteJSONTemplateRejectsInvalidJSON(t *testing.T) {
+	tmpl := template.Must(template.New("broken.tmpl.json").Funcs(TemplateFuncs).Parse(`{"text": "{{ . }}"}`))
+
+	_, err := ExecuteJSONTemplate(tmpl, `aspas " soltas`)
+	assert.ErrorIs(t, err, ErrInvalidPayload)
+
+	tmpl = template.Must(template.New("card.tmpl.json").Funcs(TemplateFuncs).Parse(`{"text": "{{ jsonEscape . }}"}`))
+
+	payload, err := ExecuteJSONTemplate(tmpl, `aspas " escapadas`)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"text": "aspas \" escapadas"}`, string(payload))
+}
+
+func mustGoogleChatTemplates(t *testing.T) *TemplateSet {
+	t.Helper()
+
+	templates, err := NewGoogleChatTemplates("")
+	require.NoError(t, err)
+
+	return templates
+}
+
+func TestTemplateSetOverridesFromDir(t *testing.T) {
+	dir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(dir, cardTemplateName), []byte(`{"text": "{{ jsonEscape .Message }} (custom)"}`), 0o644))
+
+	templates, err := NewGoogleChatTemplates(dir)
+	require.NoError(t, err)
+
+	payload, err := templates.Execute(cardTemplateName, templateData{Message: "Oi"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"text": "Oi (custom)"}`, string(payload))
+
+	// the files missing in the dir come from the embedded defaults
+	_, err = templates.Execute(brokenCardTemplateName, brokenTemplateData{Name: "Wilson"})
+	assert.NoError(t, err)
+}
+
+func TestTemplateSetKeepsPreviousOnInvalidReload(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, cardTemplateName)
+	require.NoError(t, os.WriteFile(path, []byte(`{"text": "{{ jsonEscape .Message }} v1"}`), 0o644))
+
+	templates, err := NewGoogleChatTemplates(dir)
+	require.NoError(t, err)
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"text": "{{ .Message }" v2}`), 0o644))
+	assert.Error(t, templates.Reload())
+
+	payload, err := templates.Execute(cardTemplateName, templateData{Message: "Oi"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"text": "Oi v1"}`, string(payload))
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"text": "{{ jsonEscape .Message }} v3"}`), 0o644))
+	require.NoError(t, templates.Reload())
+
+	payload, err = templates.Execute(cardTemplateName, templateData{Message: "Oi"})
+	require.NoError(t, err)
+	assert.JSONEq(t, `{"text": "Oi v3"}`, string(payload))
+}
+
+func TestTemplateSetWatchReloadsOnChange(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, cardTemplateName)
+	require.NoError(t, os.WriteFile(path, []byte(`{"text": "v1"}`), 0o644))
+
+	templates, err := NewGoogleChatTemplates(dir)
+	require.NoError(t, err)
+	require.NoError(t, templates.Watch(t.Context()))
+
+	require.NoError(t, os.WriteFile(path, []byte(`{"text": "v2"}`), 0o644))
+
+	assert.Eventually(t, func() bool {
+		payload, err := templates.Execute(cardTemplateName, templateData{})
+		return err == nil && string(payload) == `{"text": "v2"}`
+	}, 2*time.Second, 10*time.Millisecond)
+}
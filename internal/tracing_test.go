@@ -0,0 +1,66 @@
+package internal
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func useSpanRecorder(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+
+	recorder := tracetest.NewSpanRecorder()
+	previous := otel.GetTracerProvider()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+	t.Cleanup(func() { otel.SetTracerProvider(previous) })
+
+	return recorder
+}
+
+func TestTraceHandlerAddsTraceIDs(t *testing.T) {
+	useSpanRecorder(t)
+
+	var buf bytes.Buffer
+	logger := slog.New(NewTraceHandler(slog.NewJSONHandler(&buf, nil)))
+
+	ctx, span := Tracer().Start(t.Context(), "test")
+	logger.InfoContext(ctx, "hello")
+	span.End()
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, span.SpanContext().TraceID().String(), record["trace_id"])
+	assert.Equal(t, span.SpanContext().SpanID().String(), record["span_id"])
+}
+
+func TestRequestSpanPropagatesToStorer(t *testing.T) {
+	recorder := useSpanRecorder(t)
+
+	mockSender := NewMockMessageSender(t)
+	mockSender.On("SendMessage", mock.Anything, mock.Anything).Return(nil)
+
+	storer := NewMessageStorer([]Message{{Id: "1", Message: "Hello"}})
+	server := NewServer(HTTPConfig{Prefix: "/api", EnableSend: true}, storer, mockSender, NewMockAPIKeyStorer(t), NewInMemoryImageStorer(), NewInMemoryHistoryStorer(), NewInMemoryRosterStorer())
+
+	rec := httptest.NewRecorder()
+	server.echoServer.ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/api/messages/1", nil))
+	require.Equal(t, http.StatusOK, rec.Code)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+
+	storerSpan, requestSpan := spans[0], spans[1]
+	assert.Equal(t, "DumpMessageStorer.GetMessageByID", storerSpan.Name())
+	assert.Equal(t, requestSpan.SpanContext().TraceID(), storerSpan.SpanContext().TraceID())
+	assert.Equal(t, requestSpan.SpanContext().SpanID(), storerSpan.Parent().SpanID())
+}
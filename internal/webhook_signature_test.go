@@ -0,0 +1,95 @@
+package internal
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/labstack/echo/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+func TestWebhookSignatureVerifier(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	verifier := NewWebhookSignatureVerifier(WebhookSignatureConfig{
+		Enabled: true,
+		MaxAge:  5 * time.Minute,
+		Secrets: map[string]string{"ci": "ci-secret", "monitoring": "monitoring-secret"},
+	})
+	verifier.now = func() time.Time { return now }
+
+	body := []byte(`{"name":"Wilson","motive":"Subiu sem testar"}`)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	signature := SignWebhook("ci-secret", timestamp, body)
+
+	assert.ErrorIs(t, verifier.Verify("ci", "", "", body), ErrMissingSignature)
+	assert.ErrorIs(t, verifier.Verify("unknown", timestamp, signature, body), ErrUnknownSource)
+	assert.ErrorIs(t, verifier.Verify("monitoring", timestamp, signature, body), ErrBadSignature)
+	assert.ErrorIs(t, verifier.Verify("ci", timestamp, signature, []byte(`{}`)), ErrBadSignature)
+
+	oldTimestamp := strconv.FormatInt(now.Add(-10*time.Minute).Unix(), 10)
+	assert.ErrorIs(t, verifier.Verify("ci", oldTimestamp, SignWebhook("ci-secret", oldTimestamp, body), body), ErrStaleTimestamp)
+
+	assert.NoError(t, verifier.Verify("ci", timestamp, signature, body))
+	assert.ErrorIs(t, verifier.Verify("ci", timestamp, signature, body), ErrReplayedRequest)
+}
+
+func TestSignedBrokenWebhookSkipsAPIKey(t *testing.T) {
+	mockSender := NewMockMessageSender(t)
+	mockSender.On("SendBrokenMessage", mock.Anything, mock.Anything).Return(nil).Once()
+
+	cfg := HTTPConfig{
+		EnableSend: true,
+		Auth:       AuthConfig{Enabled: true},
+		WebhookSignature: WebhookSignatureConfig{
+			Enabled: true,
+			MaxAge:  5 * time.Minute,
+			Secrets: map[string]string{"ci": "ci-secret"},
+		},
+	}
+	server := NewServer(cfg, NewMockMessageStorer(t), mockSender, NewInMemoryAPIKeyStorer(), NewInMemoryImageStorer(), NewInMemoryHistoryStorer(), NewInMemoryRosterStorer())
+
+	body := []byte(`{"name":"Wilson","motive":"Subiu sem testar"}`)
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+
+	newRequest := func(signature string) *http.Request {
+		req := httptest.NewRequest(http.MethodPost, "/webhook/broken", bytes.NewReader(body))
+		req.Header.Set(echo.HeaderContentType, echo.MIMEApplicationJSON)
+		req.Header.Set(webhookSourceHeader, "ci")
+		req.Header.Set(webhookTimestampHeader, timestamp)
+		req.Header.Set(webhookSignatureHeader, signature)
+		return req
+	}
+
+	rec := httptest.NewRecorder()
+	server.echoServer.ServeHTTP(rec, newRequest("sha256=deadbeef"))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+
+	rec = httptest.NewRecorder()
+	server.echoServer.ServeHTTP(rec, newRequest(SignWebhook("ci-secret", timestamp, body)))
+	assert.Equal(t, http.StatusOK, rec.Code)
+}
+
+func TestWebhookSignatureVerifierBodyOnly(t *testing.T) {
+	verifier := NewWebhookSignatureVerifier(WebhookSignatureConfig{
+		Enabled:  true,
+		MaxAge:   5 * time.Minute,
+		Secrets:  map[string]string{"github": "github-secret", "ci": "ci-secret"},
+		BodyOnly: []string{"github"},
+	})
+
+	body := []byte(`{"action":"completed"}`)
+
+	assert.ErrorIs(t, verifier.Verify("github", "", SignWebhookBody("other-secret", body), body), ErrBadSignature)
+	assert.NoError(t, verifier.Verify("github", "", SignWebhookBody("github-secret", body), body))
+
+	// a redelivery carries the same signature and is left to the receiver
+	assert.NoError(t, verifier.Verify("github", "", SignWebhookBody("github-secret", body), body))
+
+	// the other sources still need the timestamp
+	assert.ErrorIs(t, verifier.Verify("ci", "", SignWebhookBody("ci-secret", body), body), ErrMissingSignature)
+}
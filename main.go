@@ -7,9 +7,12 @@ import (
 	"os"
 	"os/signal"
 	"syscall"
+	"time"
 
 	"github.com/taldoflemis/wilson-bot/internal"
+	"github.com/taldoflemis/wilson-bot/internal/ci"
 	"github.com/taldoflemis/wilson-bot/internal/discord"
+	"github.com/taldoflemis/wilson-bot/internal/slack"
 )
 
 func main() {
@@ -27,9 +30,9 @@ func main() {
 		os.Exit(retcode)
 	}()
 
-	logger := slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+	logger := slog.New(internal.NewTraceHandler(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
 		AddSource: true,
-	}))
+	})))
 	slog.SetDefault(logger)
 
 	cfg, err := internal.LoadConfig(ctx)
@@ -38,6 +41,20 @@ func main() {
 		return
 	}
 
+	shutdownTracing, err := internal.SetupTracing(ctx, cfg.TracingConfig)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to setup tracing", slog.Any("error", err))
+		retcode = 1
+		return
+	}
+
+	defer func() {
+		err := shutdownTracing(context.Background())
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to flush traces", slog.Any("error", err))
+		}
+	}()
+
 	messages, err := internal.GetMessages(ctx, internal.RawMessages)
 
 	if err != nil {
@@ -46,24 +63,171 @@ func main() {
 		return
 	}
 
-	discordWebhookMessageSender, err := discord.NewDiscordWebhookMessageSender(
-		cfg.DiscordWebhookConfig.WebhookURL,
-	)
+	discordTemplates, err := discord.NewTemplates(cfg.DiscordWebhookConfig.TemplateDir)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to load discord templates", slog.Any("error", err))
+		retcode = 1
+		return
+	}
+
+	googleChatTemplates, err := internal.NewGoogleChatTemplates(cfg.GoogleChatConfig.TemplateDir)
 	if err != nil {
-		slog.ErrorContext(ctx, "failed to create discord webhook message sender", slog.Any("error", err))
+		slog.ErrorContext(ctx, "failed to load google chat templates", slog.Any("error", err))
 		retcode = 1
 		return
 	}
 
+	for _, templates := range []*internal.TemplateSet{discordTemplates, googleChatTemplates} {
+		err = templates.Watch(ctx)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to watch template dir", slog.Any("error", err))
+			retcode = 1
+			return
+		}
+	}
+
+	hangup := make(chan os.Signal, 1)
+	signal.Notify(hangup, syscall.SIGHUP)
+	internal.ReloadTemplatesOnSignal(ctx, hangup, discordTemplates, googleChatTemplates)
+
+	var senders []internal.MessageSender
+	var discordWebhookMessageSender *discord.DiscordWebhookMessageSender
+	var googleChatMessageSender *internal.HardcodedGoogleChatWebhookMessageSender
+
+	if cfg.DiscordWebhookConfig.Enabled {
+		discordWebhookMessageSender, err = discord.NewDiscordWebhookMessageSender(
+			cfg.DiscordWebhookConfig.WebhookURL,
+			discordTemplates,
+			discord.NewAssets(cfg.DiscordWebhookConfig.AssetDir),
+			cfg.DiscordWebhookConfig.Threads,
+			cfg.DiscordWebhookConfig.LimitMode,
+		)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to create discord webhook message sender", slog.Any("error", err))
+			retcode = 1
+			return
+		}
+
+		if cfg.DryRun || cfg.DiscordWebhookConfig.DryRun {
+			senders = append(senders, internal.NewDryRunMessageSender("discord", discordWebhookMessageSender))
+		} else {
+			senders = append(senders, internal.NewInstrumentedMessageSender("discord", discordWebhookMessageSender))
+		}
+	}
+
+	if cfg.GoogleChatConfig.Enabled {
+		googleChatMessageSender, err = internal.NewHardcodedGoogleChatProvider(
+			cfg.GoogleChatConfig.WebhookURL,
+			googleChatTemplates,
+			cfg.GoogleChatConfig.Threads,
+			cfg.GoogleChatConfig.LimitMode,
+		)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to create google chat message sender", slog.Any("error", err))
+			retcode = 1
+			return
+		}
+
+		if cfg.DryRun || cfg.GoogleChatConfig.DryRun {
+			senders = append(senders, internal.NewDryRunMessageSender("googlechat", googleChatMessageSender))
+		} else {
+			senders = append(senders, internal.NewInstrumentedMessageSender("googlechat", googleChatMessageSender))
+		}
+	}
+
 	dumpMessageStorer := internal.NewMessageStorer(messages)
+	historyStorer := internal.NewInMemoryHistoryStorer()
+	breakageTracker := internal.NewInMemoryBreakageTracker()
+
+	imageStorer := internal.NewInMemoryImageStorer()
+	for _, image := range cfg.Images {
+		err = imageStorer.AddImage(ctx, internal.Image{
+			Id:        image.Id,
+			URL:       image.URL,
+			Tags:      image.Tags,
+			CreatedAt: time.Now(),
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to add image", slog.Any("error", err))
+			retcode = 1
+			return
+		}
+	}
+
+	rosterStorer := internal.NewInMemoryRosterStorer()
+	for _, memberConfig := range cfg.Roster {
+		member, err := internal.NewTeamMember(memberConfig, time.Now())
+		if err != nil {
+			slog.ErrorContext(ctx, "invalid team member", slog.Any("error", err))
+			retcode = 1
+			return
+		}
+
+		err = rosterStorer.AddTeamMember(ctx, member)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to add team member", slog.Any("error", err))
+			retcode = 1
+			return
+		}
+	}
+
+	// the previews and the history edits go through the same decorators
+	messagePipeline := func(next internal.MessageSender) internal.MessageSender {
+		return internal.NewMentionMessageSender(
+			internal.NewPlaceholderMessageSender(
+				internal.NewImageMessageSender(next, imageStorer),
+				cfg.Placeholders,
+			),
+			rosterStorer,
+		)
+	}
+
+	messageSender := internal.NewRecordingMessageSender(
+		messagePipeline(internal.NewMultiMessageSender(senders...)),
+		historyStorer,
+		breakageTracker,
+		rosterStorer,
+	)
+
+	if cfg.OutOfOfficeConfig.ICalFile != "" {
+		err = internal.ImportOutOfOfficeFile(ctx, rosterStorer, cfg.OutOfOfficeConfig.ICalFile)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to import out of office calendar", slog.Any("error", err))
+			retcode = 1
+			return
+		}
 
-	messageCronJob, err := internal.NewMessageCronJob(cfg.CronConfig, dumpMessageStorer, discordWebhookMessageSender)
+		calendarHangup := make(chan os.Signal, 1)
+		signal.Notify(calendarHangup, syscall.SIGHUP)
+		internal.ReimportOutOfOffice(ctx, calendarHangup, cfg.OutOfOfficeConfig.ReimportInterval, rosterStorer, cfg.OutOfOfficeConfig.ICalFile)
+	}
+
+	var rotationStorer internal.RotationStorer = internal.NewInMemoryRotationStorer()
+	if cfg.CronConfig.RotationStateFile != "" {
+		rotationStorer, err = internal.NewFileRotationStorer(cfg.CronConfig.RotationStateFile)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to load rotation state", slog.Any("error", err))
+			retcode = 1
+			return
+		}
+	}
+
+	rotation := internal.NewRotation(rosterStorer, rotationStorer)
+
+	messageCronJob, err := internal.NewMessageCronJob(cfg.CronConfig, dumpMessageStorer, messageSender, rotation)
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to create message cron job", slog.Any("error", err))
 		retcode = 1
 		return
 	}
 
+	digestCronJob, err := internal.NewDigestCronJob(cfg.DigestConfig, historyStorer, breakageTracker, messageSender)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to create digest cron job", slog.Any("error", err))
+		retcode = 1
+		return
+	}
+
 	err = messageCronJob.Start(ctx)
 	if err != nil {
 		slog.ErrorContext(ctx, "failed to start message cron job", slog.Any("error", err))
@@ -71,7 +235,118 @@ func main() {
 		return
 	}
 
-	server := internal.NewServer(cfg.HTTPConfig, dumpMessageStorer, discordWebhookMessageSender)
+	err = digestCronJob.Start(ctx)
+	if err != nil {
+		slog.ErrorContext(ctx, "failed to start digest cron job", slog.Any("error", err))
+		retcode = 1
+		return
+	}
+
+	apiKeyStorer := internal.NewInMemoryAPIKeyStorer()
+
+	if cfg.HTTPConfig.Auth.AdminKeyHash != "" {
+		err = apiKeyStorer.CreateAPIKey(ctx, internal.APIKey{
+			Id:        "bootstrap-admin",
+			Name:      "bootstrap admin",
+			Hash:      cfg.HTTPConfig.Auth.AdminKeyHash,
+			Scopes:    []internal.APIKeyScope{internal.ScopeAdmin},
+			CreatedAt: time.Now(),
+		})
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to create bootstrap admin api key", slog.Any("error", err))
+			retcode = 1
+			return
+		}
+	}
+
+	server := internal.NewServer(cfg.HTTPConfig, dumpMessageStorer, messageSender, apiKeyStorer, imageStorer, historyStorer, rosterStorer)
+
+	// the daily card only targets the rotation when it is enabled
+	if cfg.CronConfig.Rotation {
+		server.SetRotation(rotation, messageCronJob)
+	}
+	server.SetMessagePipeline(messagePipeline)
+	server.SetPlaceholderDefaults(cfg.Placeholders)
+
+	server.AddReadinessCheck("message_storer", internal.CheckMessageStorer(dumpMessageStorer))
+	server.AddReadinessCheck("message_cron", messageCronJob)
+	server.AddReadinessCheck("digest_cron", digestCronJob)
+
+	if cfg.DiscordWebhookConfig.Enabled {
+		server.AddRenderer("discord", discordWebhookMessageSender)
+		server.AddMessageEditor("discord", discordWebhookMessageSender)
+		server.AddReadinessCheck("discord_webhook", discordWebhookMessageSender)
+		if cfg.HealthConfig.ProbeWebhooks {
+			server.AddReadinessCheck("discord_webhook_probe", internal.NewWebhookProbe(cfg.DiscordWebhookConfig.WebhookURL, cfg.HealthConfig.ProbeTTL))
+		}
+	}
+
+	if cfg.GoogleChatConfig.Enabled {
+		server.AddRenderer("googlechat", googleChatMessageSender)
+		server.AddReadinessCheck("google_chat_webhook", googleChatMessageSender)
+		if cfg.HealthConfig.ProbeWebhooks {
+			server.AddReadinessCheck("google_chat_webhook_probe", internal.NewWebhookProbe(cfg.GoogleChatConfig.WebhookURL, cfg.HealthConfig.ProbeTTL))
+		}
+	}
+
+	// the command replies send their cards after answering the platform
+	background := internal.NewBackground(time.Minute)
+
+	if cfg.DiscordInteractionsConfig.Enabled {
+		interactionsHandler, err := discord.NewInteractionsHandler(
+			cfg.DiscordInteractionsConfig.PublicKey,
+			discordTemplates,
+			discord.NewAssets(cfg.DiscordWebhookConfig.AssetDir),
+			dumpMessageStorer,
+			messageSender,
+			breakageTracker,
+			cfg.Placeholders,
+			background,
+		)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to create discord interactions handler", slog.Any("error", err))
+			retcode = 1
+			return
+		}
+
+		server.Register(interactionsHandler)
+	}
+
+	if cfg.GoogleChatEventsConfig.Enabled {
+		googleChatEventsHandler, err := internal.NewGoogleChatEventsHandler(
+			cfg.GoogleChatEventsConfig,
+			googleChatTemplates,
+			dumpMessageStorer,
+			messageSender,
+			breakageTracker,
+			cfg.Placeholders,
+			background,
+		)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to create google chat events handler", slog.Any("error", err))
+			retcode = 1
+			return
+		}
+
+		server.Register(googleChatEventsHandler)
+	}
+
+	if cfg.SlackConfig.Enabled {
+		slackReceiver, err := slack.NewReceiver(cfg.SlackConfig, dumpMessageStorer, messageSender, breakageTracker, cfg.Placeholders, background)
+		if err != nil {
+			slog.ErrorContext(ctx, "failed to create slack receiver", slog.Any("error", err))
+			retcode = 1
+			return
+		}
+
+		server.Register(slackReceiver)
+		server.AddRenderer("slack", slack.NewRenderer())
+	}
+
+	if cfg.CIConfig.Enabled {
+		server.Register(ci.NewWebhookHandler(cfg.CIConfig, messageSender, breakageTracker, rosterStorer))
+	}
+
 	errChan := make(chan error)
 
 	go func() {
@@ -96,6 +371,10 @@ func main() {
 		return
 	}
 
-	// Stop the cron job gracefully
+	// Let the sends of the last commands land
+	background.Wait()
+
+	// Stop the cron jobs gracefully
 	messageCronJob.Stop(ctx)
+	digestCronJob.Stop(ctx)
 }